@@ -0,0 +1,431 @@
+package api
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTag = "default"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//errUnsupportedType causes the binding error of
+//values whose type cannot be parsed from text
+var errUnsupportedType = errors.New("unsupported type")
+
+//bindingError is thrown when a textual value
+//cannot be parsed into the type of a field
+type bindingError struct {
+	fieldType reflect.Type
+	value     string
+	err       error
+}
+
+func (e bindingError) Error() string {
+	return fmt.Sprintf("cannot bind %q to %s: %v", e.value, e.fieldType, e.err)
+}
+
+//data retrieves the validation entry reported
+//for a field which could not be bound
+func (e bindingError) data(name string) exceptions.Data {
+	return exceptions.Data{
+		Name:  name,
+		Tag:   "type=" + e.fieldType.String(),
+		Value: e.value,
+	}
+}
+
+//bindingData retrieves the validation entry
+//reported for an error thrown by setValues
+func bindingData(name string, err error) exceptions.Data {
+	if be, ok := err.(bindingError); ok {
+		return be.data(name)
+	}
+
+	return exceptions.Data{Name: name, Value: err.Error()}
+}
+
+//sent reports if any of the values is not empty
+func sent(vals []string) bool {
+	for _, val := range vals {
+		if val != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+//setValues sets a field from the textual values of a
+//query parameter, header or form field. Repeated values
+//fill slices and pointers are only allocated when a
+//value was sent, so absent values can be told apart
+//from zero ones. Values of unsupported types
+//throw a binding error
+func setValues(fieldValue reflect.Value, vals []string) error {
+	if !fieldValue.IsValid() || !fieldValue.CanSet() || !sent(vals) {
+		return nil
+	}
+
+	fieldType := fieldValue.Type()
+	if fieldType.Kind() == reflect.Slice && !isText(fieldType) {
+		slice := reflect.MakeSlice(fieldType, 0, len(vals))
+		for _, val := range vals {
+			elem, err := parseValue(fieldType.Elem(), val)
+			if err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+
+		fieldValue.Set(slice)
+		return nil
+	}
+
+	v, err := parseValue(fieldType, vals[0])
+	if err != nil {
+		return err
+	}
+
+	fieldValue.Set(v)
+	return nil
+}
+
+//isText reports if values of the type are
+//parsed through encoding.TextUnmarshaler
+func isText(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+//parseValue parses a textual value into a new value of
+//the given type. time.Time and other types implementing
+//encoding.TextUnmarshaler are parsed by themselves. Any
+//other kind (eg. structs, maps) cannot be bound
+func parseValue(t reflect.Type, val string) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		elem, err := parseValue(t.Elem(), val)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	v := reflect.New(t).Elem()
+	var err error
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		err = u.UnmarshalText([]byte(val))
+	} else if t == durationType {
+		var d time.Duration
+		d, err = time.ParseDuration(val)
+		v.SetInt(int64(d))
+	} else {
+		switch t.Kind() {
+		case reflect.String:
+			v.SetString(val)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(val, 10, t.Bits())
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			var n uint64
+			n, err = strconv.ParseUint(val, 10, t.Bits())
+			v.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(val, t.Bits())
+			v.SetFloat(f)
+		case reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(val)
+			v.SetBool(b)
+		default:
+			err = errUnsupportedType
+		}
+	}
+
+	if err != nil {
+		return reflect.Value{}, bindingError{
+			fieldType: t,
+			value:     val,
+			err:       err,
+		}
+	}
+
+	return v, nil
+}
+
+//withDefault retrieves the values sent for a field or, when
+//none was sent, the ones declared by its "default" tag.
+//Defaults of slices are separated by commas
+func withDefault(field reflect.StructField, vals []string) []string {
+	def, ok := field.Tag.Lookup(defaultTag)
+	if sent(vals) || !ok {
+		return vals
+	}
+
+	if field.Type.Kind() == reflect.Slice && !isText(field.Type) {
+		return strings.Split(def, ",")
+	}
+
+	return []string{def}
+}
+
+//setDefaults sets the fields of a body declaring a "default"
+//tag before it is decoded, so only the values sent replace
+//them. Nested structs are set recursively and fields are
+//named after their JSON path
+func setDefaults(path string, dataValue reflect.Value) []exceptions.Data {
+	failed := make([]exceptions.Data, 0)
+	dataValue = indirect(dataValue)
+	if dataValue.Kind() != reflect.Struct {
+		return failed
+	}
+	dataType := dataValue.Type()
+
+	for i := 0; i < dataType.NumField(); i++ {
+		field := dataType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		fieldValue := dataValue.Field(i)
+		if name == "" && field.Anonymous {
+			failed = append(failed, setDefaults(path, fieldValue)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if fieldValue.Kind() == reflect.Struct && !isText(field.Type) {
+			failed = append(failed, setDefaults(joinPath(path, name), fieldValue)...)
+			continue
+		}
+
+		if err := setValues(fieldValue, withDefault(field, nil)); err != nil {
+			failed = append(failed, bindingData(joinPath(path, name), err))
+		}
+	}
+
+	return failed
+}
+
+const pathTag = "path"
+const cookieTag = "cookie"
+
+//elem retrieves the value the payload points to. An
+//exception will be thrown if the payload is not a
+//non-nil pointer, as nothing could be set
+func elem(payload interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(payload)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, payloadException(payload)
+	}
+
+	return v.Elem(), nil
+}
+
+//structOf retrieves the struct the payload points to.
+//An exception will be thrown if the payload is not
+//a non-nil pointer to a struct
+func structOf(payload interface{}) (reflect.Value, error) {
+	v, err := elem(payload)
+	if err != nil {
+		return v, err
+	}
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, payloadException(payload)
+	}
+
+	return v, nil
+}
+
+//payloadException builds the exception thrown
+//for a payload which cannot be bound
+func payloadException(payload interface{}) error {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+	ex.Include(exceptions.Data{Name: "payload", Tag: fmt.Sprintf("type=%T", payload)})
+
+	return ex.Build()
+}
+
+//BindRequest fills the payload from every part of the request
+//in one pass: path parameters, query parameters, headers and
+//cookies through the "path", "query", "header" and "cookie"
+//tags, and the body through the decoder matching the
+//Content-Type ("json", "xml" or "form" tags). Every field is
+//then validated and the failures are thrown together in one
+//exception, replied with 415 if the Content-Type is not
+//supported, 413 if the body exceeds Config.MaxBodySize, 500
+//if a validation rule is not registered and 400 otherwise,
+//so handlers can return right away
+func (s *scope) BindRequest(payload interface{}) error {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+
+	dataValue, err := elem(payload)
+	if err != nil {
+		s.Reply(http.StatusBadRequest, err)
+		return err
+	}
+
+	dataValue = indirect(dataValue)
+	if dataValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, data := range setDefaults("", dataValue) {
+		ex.Include(data)
+	}
+
+	if hasBody(s.r) {
+		dec, err := s.decoder()
+		if err != nil {
+			s.Reply(http.StatusUnsupportedMediaType, err)
+			return err
+		}
+
+		if err := dec.Decode(s.r, payload); err != nil {
+			if large := tooLarge(err); large != nil {
+				s.Reply(http.StatusRequestEntityTooLarge, large)
+				return large
+			}
+
+			if decoded, ok := err.(*exceptions.Exception); ok {
+				for _, data := range decoded.Data {
+					ex.Include(data)
+				}
+			} else {
+				ex.SetCause(err)
+				ex.Include(exceptions.Data{Value: err.Error()})
+			}
+		}
+	}
+
+	v := s.validator()
+	for _, data := range s.bindFields(v, dataValue) {
+		ex.Include(data)
+	}
+
+	if err := v.unregisteredRules(); err != nil {
+		s.Fail(err)
+		return err
+	}
+
+	if ex.IsEmpty() {
+		return nil
+	}
+
+	s.Reply(http.StatusBadRequest, ex.Build())
+	return ex.Build()
+}
+
+//bindFields sets the fields bound to a part of the request
+//other than the body and validates every field of the
+//struct. Fields are named after the key they are bound
+//to, or their JSON path if they are read from the body
+func (s *scope) bindFields(v validator, dataValue reflect.Value) []exceptions.Data {
+	failed := make([]exceptions.Data, 0)
+	dataType := dataValue.Type()
+
+	for i := 0; i < dataType.NumField(); i++ {
+		field := dataType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldValue := dataValue.Field(i)
+		tags := field.Tag.Get(validationTag)
+
+		if key, vals, ok := s.values(field); ok && fieldValue.CanSet() {
+			//the body decoder may have matched the field by name
+			fieldValue.Set(reflect.Zero(field.Type))
+			vals = withDefault(field, vals)
+			if err := setValues(fieldValue, vals); err != nil {
+				failed = append(failed, bindingData(key, err))
+				continue
+			}
+
+			failed = append(failed, v.value(key, indirect(fieldValue), dataValue, tags, sent(vals))...)
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		if name == "" && field.Anonymous {
+			if embedded := indirect(fieldValue); embedded.Kind() == reflect.Struct {
+				failed = append(failed, s.bindFields(v, embedded)...)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Tag.Get(formTag)
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		failed = append(failed, v.field(name, fieldValue, dataValue, tags)...)
+	}
+
+	return failed
+}
+
+//values retrieves the key and the values sent for a field
+//bound to the path, the query, a header or a cookie.
+//Returns false if the field is not bound to any of them
+func (s *scope) values(field reflect.StructField) (string, []string, bool) {
+	if key, ok := field.Tag.Lookup(pathTag); ok {
+		if val, found := s.p[strings.ToLower(key)]; found {
+			return key, []string{val}, true
+		}
+		return key, nil, true
+	}
+
+	if key, ok := field.Tag.Lookup(queryTag); ok {
+		return key, s.r.URL.Query()[key], true
+	}
+
+	if key, ok := field.Tag.Lookup(headerTag); ok {
+		return key, s.r.Header.Values(key), true
+	}
+
+	if key, ok := field.Tag.Lookup(cookieTag); ok {
+		vals := make([]string, 0)
+		for _, c := range s.r.Cookies() {
+			if c.Name == key {
+				vals = append(vals, c.Value)
+			}
+		}
+		return key, vals, true
+	}
+
+	return "", nil, false
+}
+
+//hasBody reports if the request was sent with a body
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
@@ -0,0 +1,463 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Filter struct {
+	Int8     int8          `query:"i8" header:"I8"`
+	Int64    int64         `query:"i64" header:"I64"`
+	Uint     uint          `query:"u" header:"U"`
+	Uint16   uint16        `query:"u16" header:"U16"`
+	Float    float64       `query:"f" header:"F"`
+	Since    time.Time     `query:"since" header:"Since"`
+	Timeout  time.Duration `query:"timeout" header:"Timeout"`
+	Limit    *int          `query:"limit" header:"Limit"`
+	Offset   *int          `query:"offset" header:"Offset"`
+	Tags     []string      `query:"tag" header:"Tag"`
+	Ids      []int         `query:"id" header:"Id"`
+	Address  net.IP        `query:"ip" header:"Ip"`
+	Optional *time.Time    `query:"optional" header:"Optional"`
+}
+
+func TestScope_Query_types(t *testing.T) {
+	//given
+	var actual Filter
+	url := "/some-url?i8=-8&i64=64&u=1&u16=16&f=1.5&since=2022-12-31T10:00:00Z" +
+		"&timeout=1m30s&limit=0&tag=a&tag=b&id=1&id=2&ip=10.0.0.1"
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	scope := scope{
+		r: req,
+	}
+	limit := 0
+	expected := Filter{
+		Int8:    -8,
+		Int64:   64,
+		Uint:    1,
+		Uint16:  16,
+		Float:   1.5,
+		Since:   time.Date(2022, 12, 31, 10, 0, 0, 0, time.UTC),
+		Timeout: 90 * time.Second,
+		Limit:   &limit,
+		Tags:    []string{"a", "b"},
+		Ids:     []int{1, 2},
+		Address: net.ParseIP("10.0.0.1"),
+	}
+
+	//when
+	err := scope.ValidateQuery(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("ValidateQuery() got %v but want %v", err, nil)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ValidateQuery() got %+v but want %+v", actual, expected)
+	}
+}
+
+func TestScope_Headers_types(t *testing.T) {
+	//given
+	var actual Filter
+	req, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	req.Header.Set("U16", "16")
+	req.Header.Set("Timeout", "2s")
+	req.Header.Add("Tag", "a")
+	req.Header.Add("Tag", "b")
+	scope := scope{
+		r: req,
+	}
+
+	//when
+	err := scope.ValidateHeaders(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("ValidateHeaders() got %v but want %v", err, nil)
+	}
+
+	if actual.Uint16 != 16 {
+		t.Errorf("ValidateHeaders() got %v but want %v", actual.Uint16, 16)
+	}
+
+	if actual.Timeout != 2*time.Second {
+		t.Errorf("ValidateHeaders() got %v but want %v", actual.Timeout, 2*time.Second)
+	}
+
+	if !reflect.DeepEqual(actual.Tags, []string{"a", "b"}) {
+		t.Errorf("ValidateHeaders() got %v but want %v", actual.Tags, []string{"a", "b"})
+	}
+
+	if actual.Limit != nil {
+		t.Errorf("ValidateHeaders() got %v but want %v", actual.Limit, nil)
+	}
+}
+
+func TestScope_Query_typeErrors(t *testing.T) {
+	//given
+	type Page struct {
+		Limit   *int              `query:"limit" validate:"required,min=1"`
+		Small   int8              `query:"small"`
+		Count   uint              `query:"count"`
+		Since   time.Time         `query:"since"`
+		Timeout time.Duration     `query:"timeout"`
+		Ids     []int             `query:"id"`
+		Active  bool              `query:"active"`
+		Range   struct{}          `query:"range"`
+		Filters map[string]string `query:"filters"`
+		Phase   complex64         `query:"phase"`
+	}
+	var actual Page
+	url := "/some-url?limit=ten&small=300&count=-1&since=yesterday&timeout=soon&id=1&id=x&active=maybe&range=1-2&filters=a&phase=1"
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	scope := scope{
+		r: req,
+	}
+	expected := []exceptions.Data{
+		{Name: "Limit", Tag: "type=int", Value: "ten"},
+		{Name: "Small", Tag: "type=int8", Value: "300"},
+		{Name: "Count", Tag: "type=uint", Value: "-1"},
+		{Name: "Since", Tag: "type=time.Time", Value: "yesterday"},
+		{Name: "Timeout", Tag: "type=time.Duration", Value: "soon"},
+		{Name: "Ids", Tag: "type=int", Value: "x"},
+		{Name: "Active", Tag: "type=bool", Value: "maybe"},
+		{Name: "Range", Tag: "type=struct {}", Value: "1-2"},
+		{Name: "Filters", Tag: "type=map[string]string", Value: "a"},
+		{Name: "Phase", Tag: "type=complex64", Value: "1"},
+	}
+
+	//when
+	err := scope.ValidateQuery(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateQuery() exception expected but got %v", err)
+	}
+
+	if ex.Code != exceptions.ResourceInvalidCode {
+		t.Errorf("ValidateQuery() got %v but want %v", ex.Code, exceptions.ResourceInvalidCode)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateQuery() got %v but want %v", ex.Data, expected)
+	}
+}
+
+func TestScope_Query_pointerRules(t *testing.T) {
+	//given
+	type Page struct {
+		Limit  *int `query:"limit" validate:"required,min=1"`
+		Offset *int `query:"offset" validate:"min=0"`
+	}
+	var actual Page
+	url := "/some-url?limit=0"
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	scope := scope{
+		r: req,
+	}
+	expected := []exceptions.Data{
+		{Name: "Limit", Tag: "min=1", Value: 0},
+	}
+
+	//when
+	err := scope.ValidateQuery(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateQuery() exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateQuery() got %v but want %v", ex.Data, expected)
+	}
+
+	if actual.Offset != nil {
+		t.Errorf("ValidateQuery() got %v but want %v", actual.Offset, nil)
+	}
+}
+
+func TestScope_Query_defaults(t *testing.T) {
+	//given
+	type Page struct {
+		Limit   int           `query:"limit" default:"20" validate:"max=100"`
+		Offset  *int          `query:"offset" default:"0"`
+		Sort    string        `query:"sort" default:"desc" validate:"oneof=asc desc"`
+		Tags    []string      `query:"tag" default:"a,b"`
+		Timeout time.Duration `query:"timeout" default:"5s"`
+		Since   time.Time     `query:"since" default:"2022-12-31T00:00:00Z"`
+	}
+	var actual Page
+	url := "/some-url?sort=asc"
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	scope := scope{
+		r: req,
+	}
+	offset := 0
+	expected := Page{
+		Limit:   20,
+		Offset:  &offset,
+		Sort:    "asc",
+		Tags:    []string{"a", "b"},
+		Timeout: 5 * time.Second,
+		Since:   time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	//when
+	err := scope.ValidateQuery(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("ValidateQuery() got %v but want %v", err, nil)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ValidateQuery() got %+v but want %+v", actual, expected)
+	}
+}
+
+func TestScope_Headers_defaults(t *testing.T) {
+	//given
+	type Paging struct {
+		Limit int `header:"X-Limit" default:"500" validate:"max=100"`
+	}
+	var actual Paging
+	req, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	scope := scope{
+		r: req,
+	}
+	expected := []exceptions.Data{
+		{Name: "Limit", Tag: "max=100", Value: 500},
+	}
+
+	//when
+	err := scope.ValidateHeaders(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateHeaders() exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateHeaders() got %v but want %v", ex.Data, expected)
+	}
+}
+
+func TestScope_JsonBody_defaults(t *testing.T) {
+	//given
+	type Options struct {
+		Notify  bool `json:"notify" default:"true"`
+		Retries int  `json:"retries" default:"3"`
+	}
+	type Order struct {
+		Quantity int     `json:"quantity" default:"1" validate:"min=1"`
+		Currency string  `json:"currency" default:"USD"`
+		Options  Options `json:"options"`
+	}
+	body := `{"quantity": 3, "options": {"notify": false}}`
+	req := httptest.NewRequest(http.MethodPost, "/some-url", strings.NewReader(body))
+	scope := scope{
+		r: req,
+	}
+	var actual Order
+	expected := Order{
+		Quantity: 3,
+		Currency: "USD",
+		Options:  Options{Notify: false, Retries: 3},
+	}
+
+	//when
+	err := scope.ValidateJsonBody(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("ValidateJsonBody() got %v but want %v", err, nil)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ValidateJsonBody() got %+v but want %+v", actual, expected)
+	}
+}
+
+func TestScope_Bind_invalidDefault(t *testing.T) {
+	//given
+	type Order struct {
+		Quantity int `json:"quantity" default:"one"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/some-url", strings.NewReader(`{"quantity": 2}`))
+	scope := scope{
+		r: req,
+	}
+	var actual Order
+	expected := []exceptions.Data{
+		{Name: "quantity", Tag: "type=int", Value: "one"},
+	}
+
+	//when
+	err := scope.Bind(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("Bind() exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("Bind() got %v but want %v", ex.Data, expected)
+	}
+
+	if scope.s != http.StatusBadRequest {
+		t.Errorf("Bind() got %v but want %v", scope.s, http.StatusBadRequest)
+	}
+}
+
+type UpdateOrder struct {
+	Id       int       `path:"id" validate:"min=1"`
+	DryRun   bool      `query:"dry_run"`
+	Tenant   string    `header:"X-Tenant" validate:"required"`
+	Session  string    `cookie:"session" validate:"required"`
+	Quantity int       `json:"quantity" form:"quantity" validate:"min=1"`
+	Lines    []string  `json:"lines" form:"lines" validate:"dive,min=2"`
+	Since    time.Time `query:"since"`
+}
+
+func TestScope_BindRequest(t *testing.T) {
+	//given
+	body := `{"quantity": 2, "lines": ["ab", "cd"], "Id": 7, "DryRun": true}`
+	req := httptest.NewRequest(http.MethodPut, "/orders/5?since=2022-12-31T00:00:00Z", strings.NewReader(body))
+	req.Header.Set("Content-Type", MediaTypeJSON)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	scope := scope{
+		r: req,
+		p: map[string]string{"id": "5"},
+	}
+	var actual UpdateOrder
+	expected := UpdateOrder{
+		Id:       5,
+		Tenant:   "acme",
+		Session:  "abc",
+		Quantity: 2,
+		Lines:    []string{"ab", "cd"},
+		Since:    time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	//when
+	err := scope.BindRequest(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("BindRequest() got %v but want %v", err, nil)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("BindRequest() got %+v but want %+v", actual, expected)
+	}
+}
+
+func TestScope_BindRequest_form(t *testing.T) {
+	//given
+	body := `quantity=3&lines=ab&lines=cd`
+	req := httptest.NewRequest(http.MethodPost, "/orders/5", strings.NewReader(body))
+	req.Header.Set("Content-Type", MediaTypeForm)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	scope := scope{
+		r: req,
+		p: map[string]string{"id": "5"},
+	}
+	var actual UpdateOrder
+
+	//when
+	err := scope.BindRequest(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("BindRequest() got %v but want %v", err, nil)
+	}
+
+	if actual.Quantity != 3 {
+		t.Errorf("BindRequest() got %v but want %v", actual.Quantity, 3)
+	}
+
+	if !reflect.DeepEqual(actual.Lines, []string{"ab", "cd"}) {
+		t.Errorf("BindRequest() got %v but want %v", actual.Lines, []string{"ab", "cd"})
+	}
+}
+
+func TestScope_BindRequest_error(t *testing.T) {
+	//given
+	body := `{"quantity": 0, "lines": ["a"]}`
+	req := httptest.NewRequest(http.MethodPut, "/orders/x?since=yesterday", strings.NewReader(body))
+	scope := scope{
+		r: req,
+		p: map[string]string{"id": "x"},
+	}
+	var actual UpdateOrder
+	expected := []exceptions.Data{
+		{Name: "id", Tag: "type=int", Value: "x"},
+		{Name: "X-Tenant", Tag: "required", Value: ""},
+		{Name: "session", Tag: "required", Value: ""},
+		{Name: "quantity", Tag: "min=1", Value: 0},
+		{Name: "lines[0]", Tag: "min=2", Value: "a"},
+		{Name: "since", Tag: "type=time.Time", Value: "yesterday"},
+	}
+
+	//when
+	err := scope.BindRequest(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("BindRequest() exception expected but got %v", err)
+	}
+
+	if ex.Code != exceptions.ResourceInvalidCode {
+		t.Errorf("BindRequest() got %v but want %v", ex.Code, exceptions.ResourceInvalidCode)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("BindRequest() got %v but want %v", ex.Data, expected)
+	}
+
+	if scope.s != http.StatusBadRequest {
+		t.Errorf("BindRequest() got %v but want %v", scope.s, http.StatusBadRequest)
+	}
+}
+
+func TestScope_BindRequest_unsupported(t *testing.T) {
+	//given
+	req := httptest.NewRequest(http.MethodPost, "/orders/5", strings.NewReader("text"))
+	req.Header.Set("Content-Type", "text/plain")
+	scope := scope{
+		r: req,
+	}
+	var actual UpdateOrder
+
+	//when
+	err := scope.BindRequest(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("BindRequest() exception expected but got %v", err)
+	}
+
+	if ex.Code != exceptions.ResourceUnsupportedCode {
+		t.Errorf("BindRequest() got %v but want %v", ex.Code, exceptions.ResourceUnsupportedCode)
+	}
+
+	if scope.s != http.StatusUnsupportedMediaType {
+		t.Errorf("BindRequest() got %v but want %v", scope.s, http.StatusUnsupportedMediaType)
+	}
+}
@@ -1,17 +1,282 @@
 package api
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"math/big"
+	"net"
+	"net/url"
 	"time"
 )
 
+//serialBits is the size of the random serial
+//of the certificates issued by an authority
+const serialBits = 128
+
 type CertificateSubject struct {
+	CommonName    string
 	Organization  []string
 	Country       []string
 	Province      []string
 	Locality      []string
 	StreetAddress []string
 	PostalCode    []string
+
+	//SerialNumber identifies the certificates created
+	//by GenerateCertificate. Certificates created by a
+	//CertificateAuthority get a random serial instead
 	SerialNumber  int64
 	CertNotBefore time.Time
 	CertNotAfter  time.Time
+
+	//DNSNames & IPAddresses are the subject alternative
+	//names the certificate is valid for. When both are
+	//empty they are taken from the Service urls
+	DNSNames    []string
+	IPAddresses []net.IP
+}
+
+//template creates the x509 template
+//shared by every certificate
+func (c CertificateSubject) template() *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(c.SerialNumber),
+		Subject: pkix.Name{
+			CommonName:    c.CommonName,
+			Organization:  c.Organization,
+			Country:       c.Country,
+			Province:      c.Province,
+			Locality:      c.Locality,
+			StreetAddress: c.StreetAddress,
+			PostalCode:    c.PostalCode,
+		},
+		DNSNames:              c.DNSNames,
+		IPAddresses:           c.IPAddresses,
+		NotBefore:             c.CertNotBefore,
+		NotAfter:              c.CertNotAfter,
+		BasicConstraintsValid: true,
+	}
+}
+
+//leafTemplate creates the template of a certificate
+//serving and authenticating the given service
+func (c CertificateSubject) leafTemplate(service Service, key crypto.Signer) *x509.Certificate {
+	cert := c.template()
+	cert.SubjectKeyId = []byte(service.Id)
+	cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth}
+	cert.KeyUsage = x509.KeyUsageDigitalSignature
+
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		cert.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	if cert.Subject.CommonName == "" {
+		cert.Subject.CommonName = service.Name
+	}
+
+	if len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 0 {
+		cert.DNSNames, cert.IPAddresses = serviceNames(service)
+	}
+
+	return cert
+}
+
+//CertificateAuthority signs the certificates of local
+//services so a single root needs to be trusted
+type CertificateAuthority struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+//Sign creates a certificate for the given service
+//signed by the authority with a random serial. An
+//exception will be thrown if the certificate
+//cannot be generated
+func (ca *CertificateAuthority) Sign(subject CertificateSubject, key crypto.Signer, service Service) (tls.Certificate, error) {
+	template := subject.leafTemplate(service, key)
+	serial, err := randomSerial()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.SerialNumber = serial
+
+	return createCertificate(template, ca.Certificate, key, ca.Key)
+}
+
+//PEM retrieves the PEM encoded certificate of the
+//authority, which can be trusted by browsers
+func (ca *CertificateAuthority) PEM() []byte {
+	return EncodeCertificatePEM(ca.Certificate.Raw)
+}
+
+//NewCertificateAuthority creates a self-signed root
+//certificate with a random serial able to sign service
+//certificates. An exception will be thrown if it
+//cannot be generated
+func NewCertificateAuthority(subject CertificateSubject, key crypto.Signer) (*CertificateAuthority, error) {
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	template := subject.template()
+	template.SerialNumber = serial
+	template.IsCA = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	cert, err := createCertificate(template, template, key, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificateAuthority{
+		Certificate: cert.Leaf,
+		Key:         key,
+	}, nil
+}
+
+//GenerateCertificate creates a self-signed certificate
+//for the given service. An exception will be thrown
+//if the certificate cannot be generated
+func GenerateCertificate(subject CertificateSubject, key crypto.Signer, service Service) (tls.Certificate, error) {
+	template := subject.leafTemplate(service, key)
+
+	return createCertificate(template, template, key, key)
+}
+
+//EncodeCertificatePEM encodes a DER certificate as PEM
+func EncodeCertificatePEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: der,
+	})
+}
+
+//EncodePrivateKeyPEM encodes a private key as PEM. RSA
+//keys use PKCS #1, ECDSA keys SEC 1 and any other key
+//PKCS #8. An exception will be thrown if the key
+//cannot be encoded
+func EncodePrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(k),
+		}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, keyNotEncoded(err)
+		}
+
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: der,
+		}), nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, keyNotEncoded(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
+	}), nil
+}
+
+func keyNotEncoded(err error) error {
+	e := exceptions.NewBuilder()
+	e.SetCode(exceptions.ResourceNotEncodedCode)
+	e.SetMessage(exceptions.ResourceNotEncodedMessage)
+	e.SetCause(err)
+
+	return e.Build()
+}
+
+//randomSerial generates a 128 bits serial number so
+//certificates issued by an authority are unique as
+//RFC 5280 requires. An exception will be thrown if
+//no random number can be generated
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialBits))
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotGeneratedCode)
+		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
+		e.SetCause(err)
+
+		return nil, e.Build()
+	}
+
+	return serial, nil
+}
+
+func createCertificate(template, parent *x509.Certificate, key, parentKey crypto.Signer) (tls.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotGeneratedCode)
+		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
+		e.SetCause(err)
+
+		return tls.Certificate{}, e.Build()
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotEncodedCode)
+		e.SetMessage(exceptions.ResourceNotEncodedMessage)
+		e.SetCause(err)
+
+		return tls.Certificate{}, e.Build()
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+//serviceNames extracts the host names and addresses
+//a service is reached through from its urls
+func serviceNames(service Service) ([]string, []net.IP) {
+	hosts := make([]string, 0, 2)
+
+	if u, err := url.Parse(service.External); err == nil && u.Hostname() != "" {
+		hosts = append(hosts, u.Hostname())
+	}
+
+	if host, _, err := net.SplitHostPort(service.Internal); err == nil && host != "" {
+		hosts = append(hosts, host)
+	}
+
+	dnsNames := make([]string, 0)
+	ipAddresses := make([]net.IP, 0)
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		if ip := net.ParseIP(host); ip != nil {
+			ipAddresses = append(ipAddresses, ip)
+		} else {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+
+	return dnsNames, ipAddresses
 }
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testSubject(serial int64) CertificateSubject {
+	return CertificateSubject{
+		Organization:  []string{"o1"},
+		SerialNumber:  serial,
+		CertNotBefore: time.Now(),
+		CertNotAfter:  time.Now().AddDate(0, 0, 1),
+	}
+}
+
+func TestGenerateCertificate_serviceNames(t *testing.T) {
+	//given
+	key, _ := GenerateECDSAKey(elliptic.P256())
+	service := Service{
+		Id:       "i1",
+		Name:     "n1",
+		Internal: "127.0.0.1:50000",
+		External: "https://localhost:50000",
+	}
+
+	//when
+	cert, err := GenerateCertificate(testSubject(1), key, service)
+
+	//then
+	if err != nil {
+		t.Fatalf("GenerateCertificate(), got unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(cert.Leaf.DNSNames, []string{"localhost"}) {
+		t.Errorf("GenerateCertificate(), got %v but want %v", cert.Leaf.DNSNames, []string{"localhost"})
+	}
+
+	if len(cert.Leaf.IPAddresses) != 1 || !cert.Leaf.IPAddresses[0].Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("GenerateCertificate(), got %v but want %v", cert.Leaf.IPAddresses, "127.0.0.1")
+	}
+
+	if cert.Leaf.Subject.CommonName != "n1" {
+		t.Errorf("GenerateCertificate(), got %v but want %v", cert.Leaf.Subject.CommonName, "n1")
+	}
+
+	if !cert.Leaf.BasicConstraintsValid || cert.Leaf.IsCA {
+		t.Errorf("GenerateCertificate(), leaf certificate expected")
+	}
+
+	if err := cert.Leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("VerifyHostname(), got unexpected error %v", err)
+	}
+}
+
+func TestGenerateCertificate_subjectNames(t *testing.T) {
+	//given
+	key, _ := GenerateEd25519Key()
+	subject := testSubject(1)
+	subject.DNSNames = []string{"api.company.local"}
+	service := Service{
+		External: "https://localhost:50000",
+	}
+
+	//when
+	cert, err := GenerateCertificate(subject, key, service)
+
+	//then
+	if err != nil {
+		t.Fatalf("GenerateCertificate(), got unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(cert.Leaf.DNSNames, subject.DNSNames) {
+		t.Errorf("GenerateCertificate(), got %v but want %v", cert.Leaf.DNSNames, subject.DNSNames)
+	}
+}
+
+func TestCertificateAuthority_Sign(t *testing.T) {
+	keys := []struct {
+		name string
+		key  func() crypto.Signer
+	}{
+		{"rsa", func() crypto.Signer { k, _ := GeneratePrivateKey(1024); return k }},
+		{"ecdsa", func() crypto.Signer { k, _ := GenerateECDSAKey(elliptic.P256()); return k }},
+		{"ed25519", func() crypto.Signer { k, _ := GenerateEd25519Key(); return k }},
+	}
+	for _, tt := range keys {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			ca, err := NewCertificateAuthority(testSubject(1), tt.key())
+			if err != nil {
+				t.Fatalf("NewCertificateAuthority(), got unexpected error %v", err)
+			}
+			roots := x509.NewCertPool()
+			roots.AppendCertsFromPEM(ca.PEM())
+
+			//when
+			cert, err := ca.Sign(testSubject(2), tt.key(), Service{Name: "n1", External: "https://localhost:50000"})
+
+			//then
+			if err != nil {
+				t.Fatalf("Sign(), got unexpected error %v", err)
+			}
+
+			_, err = cert.Leaf.Verify(x509.VerifyOptions{
+				DNSName:   "localhost",
+				Roots:     roots,
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if err != nil {
+				t.Errorf("Verify(), got unexpected error %v", err)
+			}
+
+			if !ca.Certificate.IsCA {
+				t.Errorf("NewCertificateAuthority(), CA certificate expected")
+			}
+		})
+	}
+}
+
+func TestCertificateAuthority_Sign_serials(t *testing.T) {
+	//given
+	key, _ := GenerateECDSAKey(elliptic.P256())
+	ca, _ := NewCertificateAuthority(testSubject(1), key)
+	serials := map[string]bool{ca.Certificate.SerialNumber.String(): true}
+
+	for i := 0; i < 3; i++ {
+		//when
+		cert, err := ca.Sign(testSubject(1), key, Service{Name: "n1"})
+
+		//then
+		if err != nil {
+			t.Fatalf("Sign(), got unexpected error %v", err)
+		}
+
+		serial := cert.Leaf.SerialNumber.String()
+		if serials[serial] {
+			t.Errorf("Sign(), got duplicated serial %v", serial)
+		}
+		serials[serial] = true
+	}
+}
+
+func TestEncodePrivateKeyPEM(t *testing.T) {
+	keys := []struct {
+		name string
+		key  func() crypto.Signer
+	}{
+		{"rsa", func() crypto.Signer { k, _ := GeneratePrivateKey(1024); return k }},
+		{"ecdsa", func() crypto.Signer { k, _ := GenerateECDSAKey(elliptic.P256()); return k }},
+		{"ed25519", func() crypto.Signer { k, _ := GenerateEd25519Key(); return k }},
+	}
+	for _, tt := range keys {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			key := tt.key()
+			cert, _ := GenerateCertificate(testSubject(1), key, Service{})
+
+			//when
+			keyPEM, err := EncodePrivateKeyPEM(key)
+
+			//then
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyPEM(), got unexpected error %v", err)
+			}
+
+			if _, err := tls.X509KeyPair(EncodeCertificatePEM(cert.Certificate[0]), keyPEM); err != nil {
+				t.Errorf("X509KeyPair(), got unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ type Endpoints struct {
 type Resource struct {
 	url    string
 	routes map[string]Handler
+	router *router
 }
 
 func (r *Resource) Url() string {
@@ -30,10 +31,12 @@ func (r *Resource) Routes() map[string]Handler {
 }
 
 func (r *Resource) GetHandler(method, url string) Handler {
-	key := GenerateEndpointKey(method, url)
+	if r.router == nil {
+		return NotFound
+	}
 
-	if h, ok := r.routes[key]; ok {
-		return h
+	if rt, _, ok := r.router.Match(method, url); ok {
+		return rt.handler
 	}
 
 	return NotFound
@@ -43,31 +46,37 @@ func NewResource(url string, handlers Endpoints) Resource {
 	c := Resource{
 		url:    url,
 		routes: make(map[string]Handler),
+		router: newRouter(),
 	}
 
 	if handler := handlers.Get; handler != nil {
 		key := GenerateEndpointKey(http.MethodGet, c.url)
 		c.routes[key] = handler
+		c.router.Add(key, handler)
 	}
 
 	if handler := handlers.Post; handler != nil {
 		key := GenerateEndpointKey(http.MethodPost, c.url)
 		c.routes[key] = handler
+		c.router.Add(key, handler)
 	}
 
 	if handler := handlers.Put; handler != nil {
 		key := GenerateEndpointKey(http.MethodPut, c.url)
 		c.routes[key] = handler
+		c.router.Add(key, handler)
 	}
 
 	if handler := handlers.Patch; handler != nil {
 		key := GenerateEndpointKey(http.MethodPatch, c.url)
 		c.routes[key] = handler
+		c.router.Add(key, handler)
 	}
 
 	if handler := handlers.Delete; handler != nil {
 		key := GenerateEndpointKey(http.MethodDelete, c.url)
 		c.routes[key] = handler
+		c.router.Add(key, handler)
 	}
 
 	return c
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const (
+	MediaTypeForm      = "application/x-www-form-urlencoded"
+	MediaTypeMultipart = "multipart/form-data"
+)
+
+const formTag = "form"
+
+//DefaultMaxBodySize bounds the size of request
+//bodies when Config.MaxBodySize is not set
+const DefaultMaxBodySize = 32 << 20
+
+//DefaultMultipartMemory is the maximum size of a
+//multipart body kept in memory. Larger files
+//are stored in temporary files
+const DefaultMultipartMemory = 32 << 20
+
+//Decoder deserializes request bodies
+//of the media type it handles
+type Decoder interface {
+	MediaType() string
+	Decode(r *http.Request, v any) error
+}
+
+type jsonDecoder struct{}
+
+func (d jsonDecoder) MediaType() string {
+	return MediaTypeJSON
+}
+
+func (d jsonDecoder) Decode(r *http.Request, v any) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+type xmlDecoder struct{}
+
+func (d xmlDecoder) MediaType() string {
+	return MediaTypeXML
+}
+
+func (d xmlDecoder) Decode(r *http.Request, v any) error {
+	return xml.NewDecoder(r.Body).Decode(v)
+}
+
+type formDecoder struct{}
+
+func (d formDecoder) MediaType() string {
+	return MediaTypeForm
+}
+
+func (d formDecoder) Decode(r *http.Request, v any) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	return bindForm(v, r.PostForm, nil)
+}
+
+type multipartDecoder struct {
+	maxMemory int64
+}
+
+func (d multipartDecoder) MediaType() string {
+	return MediaTypeMultipart
+}
+
+func (d multipartDecoder) Decode(r *http.Request, v any) error {
+	if err := r.ParseMultipartForm(d.maxMemory); err != nil {
+		return err
+	}
+
+	return bindForm(v, r.MultipartForm.Value, r.MultipartForm.File)
+}
+
+//NewJSONDecoder creates the decoder used when
+//the request has no Content-Type
+func NewJSONDecoder() Decoder {
+	return jsonDecoder{}
+}
+
+//NewXMLDecoder creates a decoder for
+//the application/xml media type
+func NewXMLDecoder() Decoder {
+	return xmlDecoder{}
+}
+
+//NewFormDecoder creates a decoder for url encoded
+//forms using the "form" tag for the field names
+func NewFormDecoder() Decoder {
+	return formDecoder{}
+}
+
+//NewMultipartDecoder creates a decoder for multipart
+//forms using the "form" tag for the field names.
+//Files are bound to *multipart.FileHeader and
+//[]*multipart.FileHeader fields
+func NewMultipartDecoder(maxMemory int64) Decoder {
+	return multipartDecoder{
+		maxMemory: maxMemory,
+	}
+}
+
+//defaultDecoders are registered in every engine. The
+//first one is used when the request has no Content-Type
+func defaultDecoders() []Decoder {
+	return []Decoder{
+		NewJSONDecoder(),
+		NewXMLDecoder(),
+		NewFormDecoder(),
+		NewMultipartDecoder(DefaultMultipartMemory),
+	}
+}
+
+//RegisterDecoder includes a decoder replacing
+//the one registered for the same media type
+func (e *engine) RegisterDecoder(dec Decoder) {
+	for i, registered := range e.decoders {
+		if registered.MediaType() == dec.MediaType() {
+			e.decoders[i] = dec
+			return
+		}
+	}
+
+	e.decoders = append(e.decoders, dec)
+}
+
+//Bind decodes the request body into the payload using
+//the decoder matching the Content-Type over the values
+//of its "default" tags, then validates it using the
+//"validate" tag. The payload must be a pointer and the
+//body is bounded by Config.MaxBodySize. On failure an exception
+//is thrown and replied with 415 if the Content-Type
+//is unsupported, 413 if the body is too large, 500 if
+//a validation rule is not registered and 400 otherwise,
+//so handlers can return right away
+func (s *scope) Bind(payload interface{}) error {
+	if _, err := elem(payload); err != nil {
+		s.Reply(http.StatusBadRequest, err)
+		return err
+	}
+
+	dec, err := s.decoder()
+	if err != nil {
+		s.Reply(http.StatusUnsupportedMediaType, err)
+		return err
+	}
+
+	if err := s.defaults(payload); err != nil {
+		s.Reply(http.StatusBadRequest, err)
+		return err
+	}
+
+	if err := dec.Decode(s.r, payload); err != nil {
+		if ex := tooLarge(err); ex != nil {
+			s.Reply(http.StatusRequestEntityTooLarge, ex)
+			return ex
+		}
+
+		if ex, ok := err.(*exceptions.Exception); ok {
+			s.Reply(http.StatusBadRequest, ex)
+			return ex
+		}
+
+		ex := exceptions.NewBuilder()
+		ex.SetCode(exceptions.ResourceInvalidCode)
+		ex.SetMessage(exceptions.ResourceInvalidMessage)
+		ex.SetCause(err)
+		ex.Include(exceptions.Data{Value: err.Error()})
+
+		s.Reply(http.StatusBadRequest, ex.Build())
+		return ex.Build()
+	}
+
+	if err := s.validator().body(payload); err != nil {
+		if errors.Is(err, internalError()) {
+			s.Fail(err)
+			return err
+		}
+
+		s.Reply(http.StatusBadRequest, err)
+		return err
+	}
+
+	return nil
+}
+
+//tooLarge builds the exception thrown when the body
+//exceeds Config.MaxBodySize, nil for any other error
+func tooLarge(err error) *exceptions.Exception {
+	var maxBytes *http.MaxBytesError
+	if !errors.As(err, &maxBytes) {
+		return nil
+	}
+
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceTooLargeCode)
+	ex.SetMessage(exceptions.ResourceTooLargeMessage)
+	ex.SetCause(err)
+	ex.Include(exceptions.Data{Name: "body", Tag: fmt.Sprintf("max=%d", maxBytes.Limit)})
+
+	return ex.Build()
+}
+
+//decoder retrieves the decoder matching the Content-Type
+//of the request. An exception will be thrown if the
+//Content-Type is invalid or not supported
+func (s *scope) decoder() (Decoder, error) {
+	decoders := s.decoders
+	if len(decoders) == 0 {
+		decoders = defaultDecoders()
+	}
+
+	contentType := s.r.Header.Get("Content-Type")
+	if contentType == "" {
+		return decoders[0], nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		for _, dec := range decoders {
+			if strings.EqualFold(dec.MediaType(), mediaType) {
+				return dec, nil
+			}
+		}
+	}
+
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceUnsupportedCode)
+	ex.SetMessage(exceptions.ResourceUnsupportedMessage)
+	ex.Include(exceptions.Data{Name: "Content-Type", Value: contentType})
+
+	return nil, ex.Build()
+}
+
+var fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+
+//bindForm sets the payload fields from form values
+//and files using the "form" tag for their names. An
+//exception is thrown if any value cannot be parsed
+func bindForm(payload interface{}, values url.Values, files map[string][]*multipart.FileHeader) error {
+	dataValue, err := elem(payload)
+	if err != nil {
+		return err
+	}
+	if dataValue.Kind() != reflect.Struct {
+		return nil
+	}
+	dataType := dataValue.Type()
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+
+	for i := 0; i < dataType.NumField(); i++ {
+		field := dataType.Field(i)
+		fieldValue := dataValue.Field(i)
+		tagKey := field.Tag.Get(formTag)
+
+		if tagKey == "" || !fieldValue.CanSet() {
+			continue
+		}
+
+		switch {
+		case field.Type == fileHeaderType:
+			if fh := files[tagKey]; len(fh) > 0 {
+				fieldValue.Set(reflect.ValueOf(fh[0]))
+			}
+		case field.Type == reflect.SliceOf(fileHeaderType):
+			fieldValue.Set(reflect.ValueOf(files[tagKey]))
+		default:
+			if err := setValues(fieldValue, values[tagKey]); err != nil {
+				ex.Include(bindingData(tagKey, err))
+			}
+		}
+	}
+
+	if ex.IsEmpty() {
+		return nil
+	}
+
+	return ex.Build()
+}
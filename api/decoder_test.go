@@ -0,0 +1,276 @@
+package api
+
+import (
+	"bytes"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type Upload struct {
+	Name  string                  `json:"name" xml:"name" form:"name" validate:"required"`
+	Size  int                     `json:"size" xml:"size" form:"size"`
+	File  *multipart.FileHeader   `form:"file"`
+	Files []*multipart.FileHeader `form:"files"`
+}
+
+func TestScope_Bind(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json", MediaTypeJSON, `{"name":"str","size":123}`},
+		{"json with charset", "application/json; charset=utf-8", `{"name":"str","size":123}`},
+		{"no content type", "", `{"name":"str","size":123}`},
+		{"xml", MediaTypeXML, `<Upload><name>str</name><size>123</size></Upload>`},
+		{"form", MediaTypeForm, `name=str&size=123`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			req := httptest.NewRequest(http.MethodPost, "/some-url", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			scope := scope{r: req}
+			var actual Upload
+
+			//when
+			err := scope.Bind(&actual)
+
+			//then
+			if err != nil {
+				t.Fatalf("Bind() unexpected error %v", err)
+			}
+
+			if actual.Name != "str" {
+				t.Errorf("Bind() got %s but want %s", actual.Name, "str")
+			}
+
+			if actual.Size != 123 {
+				t.Errorf("Bind() got %v but want %v", actual.Size, 123)
+			}
+		})
+	}
+}
+
+func TestScope_Bind_multipart(t *testing.T) {
+	//given
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "str")
+	fw, _ := mw.CreateFormFile("file", "a.txt")
+	fw.Write([]byte("hello"))
+	mw.CreateFormFile("files", "b.txt")
+	mw.CreateFormFile("files", "c.txt")
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/some-url", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	scope := scope{r: req}
+	var actual Upload
+
+	//when
+	err := scope.Bind(&actual)
+
+	//then
+	if err != nil {
+		t.Fatalf("Bind() unexpected error %v", err)
+	}
+
+	if actual.Name != "str" {
+		t.Errorf("Bind() got %s but want %s", actual.Name, "str")
+	}
+
+	if actual.File == nil || actual.File.Filename != "a.txt" || actual.File.Size != 5 {
+		t.Errorf("Bind() got %v but want %v", actual.File, "a.txt")
+	}
+
+	if len(actual.Files) != 2 {
+		t.Errorf("Bind() got %v files but want %v", len(actual.Files), 2)
+	}
+}
+
+func TestScope_Bind_error(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        exceptions.Code
+	}{
+		{"unsupported media type", "text/plain", `name`, http.StatusUnsupportedMediaType, exceptions.ResourceUnsupportedCode},
+		{"invalid content type", "application/", `name`, http.StatusUnsupportedMediaType, exceptions.ResourceUnsupportedCode},
+		{"malformed json", MediaTypeJSON, `{"name}`, http.StatusBadRequest, exceptions.ResourceInvalidCode},
+		{"malformed xml", MediaTypeXML, `<Upload><name>`, http.StatusBadRequest, exceptions.ResourceInvalidCode},
+		{"malformed multipart", MediaTypeMultipart, `name`, http.StatusBadRequest, exceptions.ResourceInvalidCode},
+		{"unparsable form value", MediaTypeForm, `name=doc&size=big`, http.StatusBadRequest, exceptions.ResourceInvalidCode},
+		{"failed validation", MediaTypeJSON, `{"size":1}`, http.StatusBadRequest, exceptions.ResourceInvalidCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			req := httptest.NewRequest(http.MethodPost, "/some-url", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			scope := scope{r: req}
+			var actual Upload
+
+			//when
+			err := scope.Bind(&actual)
+
+			//then
+			ex, ok := err.(*exceptions.Exception)
+			if !ok {
+				t.Fatalf("Bind() exception expected but got %v", err)
+			}
+
+			if ex.Code != tt.code {
+				t.Errorf("Bind() got %v but want %v", ex.Code, tt.code)
+			}
+
+			if scope.s != tt.status {
+				t.Errorf("Bind() got %v but want %v", scope.s, tt.status)
+			}
+		})
+	}
+}
+
+func TestScope_payload(t *testing.T) {
+	var upload Upload
+	var nilUpload *Upload
+	var size int
+	tests := []struct {
+		name     string
+		validate func(s *scope) error
+	}{
+		{"bind value", func(s *scope) error { return s.Bind(upload) }},
+		{"bind nil pointer", func(s *scope) error { return s.Bind(nilUpload) }},
+		{"bind request value", func(s *scope) error { return s.BindRequest(upload) }},
+		{"json body value", func(s *scope) error { return s.ValidateJsonBody(upload) }},
+		{"query value", func(s *scope) error { return s.ValidateQuery(upload) }},
+		{"query not a struct", func(s *scope) error { return s.ValidateQuery(&size) }},
+		{"headers value", func(s *scope) error { return s.ValidateHeaders(upload) }},
+		{"form value", func(s *scope) error { return bindForm(upload, nil, nil) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			req := httptest.NewRequest(http.MethodPost, "/some-url", strings.NewReader(`{"name":"doc"}`))
+			scope := &scope{r: req}
+
+			//when
+			err := tt.validate(scope)
+
+			//then
+			ex, ok := err.(*exceptions.Exception)
+			if !ok || ex.Code != exceptions.ResourceInvalidCode {
+				t.Fatalf("%s, got %v but want %v", tt.name, err, exceptions.ResourceInvalidCode)
+			}
+
+			if len(ex.Data) != 1 || ex.Data[0].Name != "payload" {
+				t.Errorf("%s, got %v but want the payload type", tt.name, ex.Data)
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_maxBodySize(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int64
+		status int
+	}{
+		{"within the limit", 64, http.StatusOK},
+		{"over the limit", 8, http.StatusRequestEntityTooLarge},
+		{"unbounded", -1, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes: newRouter(),
+				config: Config{MaxBodySize: tt.limit},
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodPost, "/uploads"), func(s Scope) {
+				var upload Upload
+				if err := s.Bind(&upload); err != nil {
+					return
+				}
+				s.Reply(http.StatusOK, upload)
+			})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/uploads", strings.NewReader(`{"name":"document"}`))
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestEngine_RegisterDecoder(t *testing.T) {
+	//given
+	e := engine{
+		decoders: defaultDecoders(),
+	}
+
+	//when
+	e.RegisterDecoder(NewMultipartDecoder(1024))
+
+	//then
+	if len(e.decoders) != 4 {
+		t.Errorf("RegisterDecoder(), got %v decoders but want %v", len(e.decoders), 4)
+	}
+}
+
+func TestEngine_ServeHTTP_tooLarge(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		bind        func(s Scope, payload *Upload) error
+	}{
+		{"Bind json", MediaTypeJSON, `{"name":"document"}`, func(s Scope, payload *Upload) error { return s.Bind(payload) }},
+		{"Bind form", MediaTypeForm, "name=document", func(s Scope, payload *Upload) error { return s.Bind(payload) }},
+		{"BindRequest json", MediaTypeJSON, `{"name":"document"}`, func(s Scope, payload *Upload) error { return s.BindRequest(payload) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes:   newRouter(),
+				config:   Config{MaxBodySize: 8},
+				decoders: defaultDecoders(),
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodPost, "/uploads"), func(s Scope) {
+				var upload Upload
+				if err := tt.bind(s, &upload); err != nil {
+					return
+				}
+				s.Reply(http.StatusOK, upload)
+			})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/uploads", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			r.Header.Set(RequestIDHeader, "req-1")
+			expected := `{"code":"fwork_rtl","message":"resource too large","data":[{"name":"body","tag":"max=8"}],"request_id":"req-1"}`
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusRequestEntityTooLarge)
+			}
+
+			if w.Body.String() != expected {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), expected)
+			}
+		})
+	}
+}
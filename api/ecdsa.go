@@ -0,0 +1,33 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+)
+
+func GenerateECDSAKey(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	if curve == nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotGeneratedCode)
+		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
+		e.Include(exceptions.Data{
+			Name: "curve",
+		})
+
+		return nil, e.Build()
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotGeneratedCode)
+		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
+		e.SetCause(err)
+
+		return nil, e.Build()
+	}
+
+	return ecdsaKey, nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"crypto/elliptic"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"testing"
+)
+
+func TestGenerateECDSAKey_success(t *testing.T) {
+	//given
+	//when
+	priv, err := GenerateECDSAKey(elliptic.P256())
+
+	//then
+	if err != nil {
+		t.Errorf("GenerateECDSAKey(), failed: %v", err)
+	}
+
+	if !priv.Curve.IsOnCurve(priv.X, priv.Y) {
+		t.Errorf("GenerateECDSAKey() public key is not on the curve")
+	}
+}
+
+func TestGenerateECDSAKey_error(t *testing.T) {
+	//given
+	//when
+	_, err := GenerateECDSAKey(nil)
+
+	//then
+	e := err.(*exceptions.Exception)
+
+	if e.Code != exceptions.ResourceNotGeneratedCode {
+		t.Errorf(
+			"GenerateECDSAKey() invalid code:  got %s but want %s",
+			e.Code,
+			exceptions.ResourceNotGeneratedCode,
+		)
+	}
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+)
+
+func GenerateEd25519Key() (ed25519.PrivateKey, error) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotGeneratedCode)
+		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
+		e.SetCause(err)
+
+		return nil, e.Build()
+	}
+
+	return ed25519Key, nil
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestGenerateEd25519Key_success(t *testing.T) {
+	//given
+	//when
+	priv, err := GenerateEd25519Key()
+
+	//then
+	if err != nil {
+		t.Errorf("GenerateEd25519Key(), failed: %v", err)
+	}
+
+	if len(priv) != ed25519.PrivateKeySize {
+		t.Errorf(
+			"GenerateEd25519Key() invalid size: got %v but want %v",
+			len(priv),
+			ed25519.PrivateKeySize,
+		)
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeXML  = "application/xml"
+	MediaTypeYAML = "application/yaml"
+)
+
+//Encoder serializes replies into
+//the media type it handles
+type Encoder interface {
+	MediaType() string
+	Encode(v any) ([]byte, error)
+}
+
+type jsonEncoder struct{}
+
+func (e jsonEncoder) MediaType() string {
+	return MediaTypeJSON
+}
+
+func (e jsonEncoder) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type xmlEncoder struct{}
+
+func (e xmlEncoder) MediaType() string {
+	return MediaTypeXML
+}
+
+func (e xmlEncoder) Encode(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+type yamlEncoder struct{}
+
+func (e yamlEncoder) MediaType() string {
+	return MediaTypeYAML
+}
+
+func (e yamlEncoder) Encode(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+//NewJSONEncoder creates the encoder used
+//when the client accepts any media type
+func NewJSONEncoder() Encoder {
+	return jsonEncoder{}
+}
+
+//NewXMLEncoder creates an encoder
+//for the application/xml media type
+func NewXMLEncoder() Encoder {
+	return xmlEncoder{}
+}
+
+//NewYAMLEncoder creates an encoder
+//for the application/yaml media type
+func NewYAMLEncoder() Encoder {
+	return yamlEncoder{}
+}
+
+//defaultEncoders are registered in every engine.
+//The first one is used when the client has
+//no preference
+func defaultEncoders() []Encoder {
+	return []Encoder{
+		NewJSONEncoder(),
+		NewXMLEncoder(),
+		NewYAMLEncoder(),
+	}
+}
+
+//RegisterEncoder includes an encoder replacing
+//the one registered for the same media type
+func (e *engine) RegisterEncoder(enc Encoder) {
+	for i, registered := range e.encoders {
+		if registered.MediaType() == enc.MediaType() {
+			e.encoders[i] = enc
+			return
+		}
+	}
+
+	e.encoders = append(e.encoders, enc)
+}
+
+//negotiate selects the encoder preferred by the Accept
+//header. The first registered encoder is selected if
+//the header is absent. Returns false if the client
+//accepts none of the registered media types
+func negotiate(encoders []Encoder, accept string) (Encoder, bool) {
+	accepted := acceptable(encoders, accept)
+	if len(accepted) == 0 {
+		if len(encoders) == 0 {
+			return NewJSONEncoder(), false
+		}
+		return encoders[0], false
+	}
+
+	return accepted[0], true
+}
+
+//acceptable retrieves the encoders accepted by the
+//client sorted by preference. Every registered
+//encoder is accepted if the header is absent
+func acceptable(encoders []Encoder, accept string) []Encoder {
+	if len(encoders) == 0 {
+		encoders = defaultEncoders()
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return encoders
+	}
+
+	ranges := parseAccept(accept)
+	accepted := make([]Encoder, 0, len(encoders))
+	seen := make(map[string]bool)
+	for _, r := range ranges {
+		if r.q <= 0 {
+			break
+		}
+
+		for _, enc := range encoders {
+			mediaType := enc.MediaType()
+			if best, ok := preferred(ranges, mediaType); ok && best == r && !seen[mediaType] {
+				seen[mediaType] = true
+				accepted = append(accepted, enc)
+			}
+		}
+	}
+
+	return accepted
+}
+
+//preferred retrieves the most specific range matching
+//the media type, which sets its quality. A media type
+//is excluded by its range if its quality is 0, even
+//if a wildcard would accept it
+func preferred(ranges []mediaRange, mediaType string) (mediaRange, bool) {
+	var best mediaRange
+	found := false
+	for _, r := range ranges {
+		if r.matches(mediaType) && (!found || r.specificity() > best.specificity()) {
+			best = r
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+//mediaRange is a single entry of the Accept header
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+//matches reports if the media type is
+//within the range (eg. application/*)
+func (r mediaRange) matches(mediaType string) bool {
+	if r.mediaType == "*/*" || r.mediaType == mediaType {
+		return true
+	}
+
+	if strings.HasSuffix(r.mediaType, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*"))
+	}
+
+	return false
+}
+
+//specificity ranks exact media types
+//over partial and full wildcards
+func (r mediaRange) specificity() int {
+	switch {
+	case r.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(r.mediaType, "/*"):
+		return 1
+	}
+	return 2
+}
+
+//parseAccept parses the Accept header into media ranges
+//sorted by preference. Ranges with q=0 are sorted last
+//and kept as exclusions
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{
+			mediaType: strings.ToLower(strings.TrimSpace(params[0])),
+			q:         1,
+		}
+
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(key) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				r.q = q
+			}
+		}
+
+		if r.mediaType != "" {
+			ranges = append(ranges, r)
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
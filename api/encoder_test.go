@@ -0,0 +1,198 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"no preference", "", MediaTypeJSON, true},
+		{"any media type", "*/*", MediaTypeJSON, true},
+		{"exact media type", "application/xml", MediaTypeXML, true},
+		{"case insensitive", "Application/YAML", MediaTypeYAML, true},
+		{"quality preference", "application/json;q=0.5, application/yaml", MediaTypeYAML, true},
+		{"specific over wildcard", "*/*, application/xml", MediaTypeXML, true},
+		{"partial wildcard", "text/html, application/*;q=0.8", MediaTypeJSON, true},
+		{"excluded media type", "application/json;q=0, application/xml;q=0.1", MediaTypeXML, true},
+		{"excluded from wildcard", "application/json;q=0, */*", MediaTypeXML, true},
+		{"specific quality over wildcard", "application/json;q=0.5, application/*", MediaTypeXML, true},
+		{"everything excluded", "application/*;q=0, */*;q=0", MediaTypeJSON, false},
+		{"not acceptable", "text/html", MediaTypeJSON, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, ok := negotiate(defaultEncoders(), tt.accept)
+
+			if ok != tt.ok {
+				t.Errorf("negotiate() = %v, want %v", ok, tt.ok)
+			}
+
+			if enc.MediaType() != tt.want {
+				t.Errorf("negotiate() = %v, want %v", enc.MediaType(), tt.want)
+			}
+		})
+	}
+}
+
+//csvEncoder is a custom encoder
+//replying with a fixed body
+type csvEncoder struct{}
+
+func (e csvEncoder) MediaType() string {
+	return "text/csv"
+}
+
+func (e csvEncoder) Encode(v any) ([]byte, error) {
+	return []byte("a,b"), nil
+}
+
+func TestEngine_RegisterEncoder(t *testing.T) {
+	//given
+	e := engine{
+		encoders: defaultEncoders(),
+	}
+
+	//when
+	e.RegisterEncoder(csvEncoder{})
+	e.RegisterEncoder(csvEncoder{})
+
+	//then
+	if len(e.encoders) != 4 {
+		t.Errorf("RegisterEncoder(), got %v encoders but want %v", len(e.encoders), 4)
+	}
+
+	if enc, _ := negotiate(e.encoders, "text/csv"); enc.MediaType() != "text/csv" {
+		t.Errorf("RegisterEncoder(), got %v but want %v", enc.MediaType(), "text/csv")
+	}
+}
+
+func TestEncoders_exception(t *testing.T) {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+	ex.Include(exceptions.Data{Name: "n1", Tag: "required"})
+	tests := []struct {
+		name string
+		enc  Encoder
+		want string
+	}{
+		{"json", NewJSONEncoder(), `{"code":"fwork_ri","message":"resource invalid","data":[{"name":"n1","tag":"required"}]}`},
+		{"xml", NewXMLEncoder(), `<Exception><code>fwork_ri</code><message>resource invalid</message><data><name>n1</name><tag>required</tag></data></Exception>`},
+		{"yaml", NewYAMLEncoder(), "code: fwork_ri\nmessage: resource invalid\ndata:\n    - name: n1\n      tag: required\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.enc.Encode(ex.Build())
+
+			if err != nil {
+				t.Fatalf("Encode(), got unexpected error %v", err)
+			}
+
+			if string(actual) != tt.want {
+				t.Errorf("Encode(), got %v but want %v", string(actual), tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_negotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"json by default", "", http.StatusOK, MediaTypeJSON, `{"name":"Jhonny"}`},
+		{"xml", MediaTypeXML, http.StatusOK, MediaTypeXML, `<person><name>Jhonny</name></person>`},
+		{"yaml", MediaTypeYAML, http.StatusOK, MediaTypeYAML, "name: Jhonny\n"},
+		{"not acceptable", "text/html", http.StatusNotAcceptable, MediaTypeJSON, "{}"},
+	}
+	type person struct {
+		XMLName struct{} `json:"-" xml:"person" yaml:"-"`
+		Name    string   `json:"name" xml:"name" yaml:"name"`
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes:   newRouter(),
+				encoders: defaultEncoders(),
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users"), func(s Scope) {
+				s.Reply(http.StatusOK, person{Name: "Jhonny"})
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/users", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, tt.status)
+			}
+
+			if actual := w.Header().Get("Content-Type"); actual != tt.contentType {
+				t.Errorf("ServeHTTP(), got %v but want %v", actual, tt.contentType)
+			}
+
+			if w.Body.String() != tt.body {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_negotiation_fallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"next acceptable", "application/xml, application/json;q=0.5", http.StatusOK, MediaTypeJSON, `{"name":"Jhonny"}`},
+		{"none acceptable", "application/xml", http.StatusInternalServerError, MediaTypeXML, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes:   newRouter(),
+				encoders: defaultEncoders(),
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users"), func(s Scope) {
+				s.Reply(http.StatusOK, map[string]string{"name": "Jhonny"})
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/users", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, tt.status)
+			}
+
+			if actual := w.Header().Get("Content-Type"); actual != tt.contentType {
+				t.Errorf("ServeHTTP(), got %v but want %v", actual, tt.contentType)
+			}
+
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -14,3 +14,23 @@ type Handler func(scope Scope)
 func NotFound(scope Scope) {
 	scope.Reply(http.StatusNotFound, response.Void{})
 }
+
+//MethodNotAllowed is the default handler used if
+//the url matched but not the requested method
+func MethodNotAllowed(scope Scope) {
+	scope.Reply(http.StatusMethodNotAllowed, response.Void{})
+}
+
+//Options is the default handler used to reply
+//to OPTIONS requests. The allowed methods are
+//sent through the Allow header
+func Options(scope Scope) {
+	scope.Reply(http.StatusNoContent, response.Void{})
+}
+
+//NotAcceptable is the default handler used if
+//the client accepts none of the media types
+//the engine is able to reply with
+func NotAcceptable(scope Scope) {
+	scope.Reply(http.StatusNotAcceptable, response.Void{})
+}
@@ -21,3 +21,54 @@ func TestNotFound(t *testing.T) {
 		)
 	}
 }
+
+func TestMethodNotAllowed(t *testing.T) {
+	//given
+	scope := &scope{}
+
+	//when
+	MethodNotAllowed(scope)
+
+	//then
+	if scope.s != http.StatusMethodNotAllowed {
+		t.Errorf(
+			"MethodNotAllowed(), got %v but want %v",
+			scope.s,
+			http.StatusMethodNotAllowed,
+		)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	//given
+	scope := &scope{}
+
+	//when
+	Options(scope)
+
+	//then
+	if scope.s != http.StatusNoContent {
+		t.Errorf(
+			"Options(), got %v but want %v",
+			scope.s,
+			http.StatusNoContent,
+		)
+	}
+}
+
+func TestNotAcceptable(t *testing.T) {
+	//given
+	scope := &scope{}
+
+	//when
+	NotAcceptable(scope)
+
+	//then
+	if scope.s != http.StatusNotAcceptable {
+		t.Errorf(
+			"NotAcceptable(), got %v but want %v",
+			scope.s,
+			http.StatusNotAcceptable,
+		)
+	}
+}
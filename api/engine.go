@@ -1,17 +1,22 @@
 package api
 
 import (
-	"bytes"
-	"crypto/rand"
+	"context"
 	"crypto/rsa"
 	"crypto/tls"
-	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
 	"github.com/ravelo-systematic-solutions/fwork/exceptions"
 	"log"
-	"math/big"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 //Service holds information
@@ -27,17 +32,78 @@ type Service struct {
 
 type Config struct {
 	Service Service
+
+	//TLS references the certificates served
+	//by engines created with NewEngineWithTLS
+	TLS TLS
+
+	//ClientAuth configures mutual TLS
+	ClientAuth ClientAuth
+
+	//ShutdownTimeout bounds the time given to
+	//in-flight requests to drain once a SIGINT
+	//or SIGTERM is received
+	ShutdownTimeout time.Duration
+
+	//DrainDelay keeps serving requests for the given
+	//time once the engine is no longer ready, letting
+	//load balancers stop routing to it before its
+	//listeners are closed. It counts towards the
+	//time given to Shutdown
+	DrainDelay time.Duration
+
+	//Problems replies exceptions as RFC 7807
+	//problem details when enabled
+	Problems Problems
+
+	//Timeouts bounds the time given
+	//to handlers to reply
+	Timeouts Timeouts
+
+	//Health serves the health and readiness
+	//endpoints when enabled
+	Health Health
+
+	//MaxBodySize bounds the size in bytes of request
+	//bodies, DefaultMaxBodySize if zero. Bodies are
+	//unbounded if negative
+	MaxBodySize int64
 }
 
 type engine struct {
 	//server
 	server http.Server
 	config Config
-	routes map[string]Handler
+	routes *router
 
 	//interceptors
 	i []InterceptorI
 
+	//encoders & decoders
+	encoders []Encoder
+	decoders []Decoder
+
+	//validation rules
+	rules map[string]FieldRule
+
+	//statuses replied for each exception code
+	statuses map[exceptions.Code]int
+
+	//lifecycle
+	ready      int32
+	onStart    []StartHook
+	onShutdown []ShutdownHook
+	draining   sync.WaitGroup
+
+	//health checks
+	liveness  []HealthCheck
+	readiness []HealthCheck
+	probes    map[string]bool
+
+	//routes replying in their own media
+	//type whatever the Accept header is
+	raw map[string]bool
+
 	//cert
 	certSubject CertificateSubject
 	privateKey  rsa.PrivateKey
@@ -46,141 +112,298 @@ type engine struct {
 // Controller is a shortcut for registering controllers
 func (e *engine) Controller(c Controller) error {
 
+	_, raw := c.(rawReplier)
 	for k, h := range c.Routes() {
-		if _, ok := e.routes[k]; ok {
-			ex := exceptions.NewBuilder()
-			ex.SetCode(exceptions.ResourceDuplicatedCode)
-			ex.SetMessage(exceptions.ResourceDuplicatedMessage)
-			ex.Include(exceptions.Data{Value: k})
-
-			return ex.Build()
+		if err := e.routes.addTemplate(k, c.Url(), h); err != nil {
+			return err
 		}
 
-		e.routes[k] = h
+		if raw {
+			if e.raw == nil {
+				e.raw = make(map[string]bool)
+			}
+			e.raw[k] = true
+		}
 	}
 
 	return nil
 }
 
+//rawReplier is implemented by controllers whose handlers
+//encode their own replies (eg. Metrics), so they are
+//served whatever media types the client accepts
+type rawReplier interface {
+	repliesRaw()
+}
+
+//routedTo reports if the route matched by the scope is
+//one of the given endpoint keys. HEAD requests are
+//routed to GET handlers
+func routedTo(s *scope, keys map[string]bool) bool {
+	method := s.r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+
+	return s.route != "" && keys[GenerateEndpointKey(method, s.route)]
+}
+
 //ServeHTTP entry point for HTTP requests
 func (e *engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
-	errs := make([]exceptions.Exception, 0)
+	if limit := e.maxBodySize(); limit > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
 
 	s := NewScope(w, r)
-	key := GenerateEndpointKey(r.Method, r.URL.Path)
-	handler := e.GetHandler(key)
-
-	if len(errs) == 0 {
-		handler(s)
+	s.id = requestID(r)
+	s.w.Header().Set(RequestIDHeader, s.id)
+	s.decoders = e.decoders
+	s.rules = e.rules
+	s.statuses = e.statuses
+	s.problems = e.config.Problems
+	handler := e.resolve(s)
+
+	if accepted := acceptable(e.encoders, e.config.Problems.accept(r.Header.Get("Accept"))); len(accepted) > 0 {
+		s.enc = accepted[0]
+		s.fallbacks = accepted[1:]
+	} else {
+		s.enc, _ = negotiate(e.encoders, "")
+		if !routedTo(s, e.raw) {
+			handler = NotAcceptable
+		}
 	}
 
+	e.intercept(s, e.bound(s, handler))
 	e.DispatchResponse(s)
 }
 
+//maxBodySize retrieves the maximum size
+//of request bodies, 0 if unbounded
+func (e *engine) maxBodySize() int64 {
+	switch {
+	case e.config.MaxBodySize < 0:
+		return 0
+	case e.config.MaxBodySize == 0:
+		return DefaultMaxBodySize
+	}
+
+	return e.config.MaxBodySize
+}
+
+//resolve retrieves the handler matching the request
+//and sets its path parameters into the scope. HEAD
+//requests are served by the GET handler. Paths that
+//exist for other methods reply to OPTIONS and are
+//otherwise not allowed
+func (e *engine) resolve(s *scope) Handler {
+	path := s.r.URL.EscapedPath()
+
+	rt, params, ok := e.routes.Match(s.r.Method, path)
+	if !ok && s.r.Method == http.MethodHead {
+		rt, params, ok = e.routes.Match(http.MethodGet, path)
+	}
+
+	if ok {
+		s.p = params
+		s.route = rt.template
+		return rt.handler
+	}
+
+	allowed := e.routes.Allowed(path)
+	if len(allowed) == 0 {
+		return NotFound
+	}
+
+	s.w.Header().Set("Allow", allowHeader(allowed))
+	if s.r.Method == http.MethodOptions {
+		return Options
+	}
+
+	return MethodNotAllowed
+}
+
+//allowHeader builds the Allow header value
+//out of the methods registered for a path
+func allowHeader(methods []string) string {
+	allowed := append([]string{}, methods...)
+	if contains(allowed, http.MethodGet) && !contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if !contains(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	sort.Strings(allowed)
+
+	return strings.Join(allowed, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *engine) DispatchResponse(s *scope) {
 	s.w.Header().Set("Access-Control-Allow-Origin", "*")
-	s.w.Header().Set("Content-Type", "application/json")
+	s.w.Header().Set("Content-Type", s.mediaType())
+	s.w.Header().Add("Vary", "Accept")
+
+	if s.r.Method == http.MethodHead {
+		s.w.Header().Set("Content-Length", strconv.Itoa(len(s.b)))
+		s.w.WriteHeader(s.s)
+		return
+	}
+
 	s.w.WriteHeader(s.s)
-	s.w.Write(s.b)
+	if bodyAllowed(s.s) {
+		s.w.Write(s.b)
+	}
+}
+
+//bodyAllowed reports if a response with the
+//given status is allowed to have a body
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
 }
 
-//GetHandler retrieves the handler which needs
-//to handle the request
+//GetHandler retrieves the handler which needs to
+//handle the request identified by the given key
+//(eg. GenerateEndpointKey(http.MethodGet, "/users/1"))
 func (e *engine) GetHandler(key string) Handler {
+	method, url, ok := strings.Cut(key, "-")
+	if !ok {
+		return NotFound
+	}
 
-	if handler, ok := e.routes[key]; ok {
-		return handler
+	if rt, _, ok := e.routes.Match(method, url); ok {
+		return rt.handler
 	}
 
 	return NotFound
 }
 
+//Run calls the start hooks and serves requests until the
+//server is closed. The engine is ready once its listener
+//is bound. A SIGINT or SIGTERM drains the engine gracefully
+//through Shutdown, which also runs if the server fails so
+//the resources opened by the start hooks are released. Run
+//returns once Shutdown is done, even if it was called by
+//another goroutine. An exception is always thrown once
+//the engine stops
 func (e *engine) Run() error {
-	log.Printf(
-		"Running on %v",
-		e.config.Service.External,
-	)
-	err := e.server.ListenAndServeTLS("", "")
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	errs := make(chan error, 1)
+	if ln, err := net.Listen("tcp", e.address()); err != nil {
+		errs <- err
+	} else {
+		go func() {
+			errs <- e.server.ServeTLS(ln, "", "")
+		}()
+
+		log.Printf(
+			"Running on %v",
+			e.config.Service.External,
+		)
+		atomic.StoreInt32(&e.ready, 1)
+	}
+
+	var err error
+	select {
+	case err = <-errs:
+		atomic.StoreInt32(&e.ready, 0)
+	case sig := <-stop:
+		log.Printf(
+			"Shutting down on %v",
+			sig,
+		)
+	}
+
+	//a server closed through Shutdown already
+	//called the hooks, or is still calling them
+	if err == http.ErrServerClosed {
+		e.draining.Wait()
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), e.shutdownTimeout())
+		defer cancel()
+
+		if shutdownErr := e.Shutdown(ctx); shutdownErr != nil {
+			log.Printf(
+				"Failed to shutdown: %v",
+				shutdownErr,
+			)
+		}
+	}
+
+	if err == nil {
+		err = <-errs
+	}
 
 	ex := exceptions.NewBuilder()
 	ex.SetCode(exceptions.ResourceClosedCode)
 	ex.SetMessage(exceptions.ResourceClosedMessage)
-	ex.Include(exceptions.Data{Value: err.Error()})
+	ex.SetCause(err)
 
 	return ex.Build()
 }
 
-func NewEngine(certSubject CertificateSubject, privateKey *rsa.PrivateKey, config Config) (*engine, error) {
-
-	cert := &x509.Certificate{
-		SerialNumber: big.NewInt(certSubject.SerialNumber),
-		Subject: pkix.Name{
-			Organization:  certSubject.Organization,
-			Country:       certSubject.Country,
-			Province:      certSubject.Province,
-			Locality:      certSubject.Locality,
-			StreetAddress: certSubject.StreetAddress,
-			PostalCode:    certSubject.PostalCode,
-		},
-		SubjectKeyId:          []byte(config.Service.Id),
-		NotBefore:             certSubject.CertNotBefore,
-		NotAfter:              certSubject.CertNotAfter,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:              x509.KeyUsageDigitalSignature,
-		BasicConstraintsValid: false,
+//address retrieves the address the
+//server listens on, :https by default
+func (e *engine) address() string {
+	if e.server.Addr == "" {
+		return ":https"
 	}
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, cert, cert, &privateKey.PublicKey, privateKey)
-	if err != nil {
-		e := exceptions.NewBuilder()
-		e.SetCode(exceptions.ResourceNotGeneratedCode)
-		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
-		e.Include(exceptions.Data{Value: err.Error()})
+	return e.server.Addr
+}
 
-		return nil, e.Build()
-	}
+//NewEngine creates an engine serving a certificate
+//self-signed with the given subject and private key
+func NewEngine(certSubject CertificateSubject, privateKey *rsa.PrivateKey, config Config) (*engine, error) {
 
-	certPEM := new(bytes.Buffer)
-	err = pem.Encode(certPEM, &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: certBytes,
-	})
+	serverCert, err := GenerateCertificate(certSubject, privateKey, config.Service)
 	if err != nil {
-		e := exceptions.NewBuilder()
-		e.SetCode(exceptions.ResourceNotEncodedCode)
-		e.SetMessage(exceptions.ResourceNotEncodedMessage)
-		e.Include(exceptions.Data{Value: err.Error()})
+		return nil, err
+	}
 
-		return nil, e.Build()
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
 	}
 
-	certPrivKeyPEM := new(bytes.Buffer)
-	pem.Encode(certPrivKeyPEM, &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-	})
+	e, err := newEngine(tlsConfig, config)
 	if err != nil {
-		e := exceptions.NewBuilder()
-		e.SetCode(exceptions.ResourceNotEncodedCode)
-		e.SetMessage(exceptions.ResourceNotEncodedMessage)
-		e.Include(exceptions.Data{Value: err.Error()})
-
-		return nil, e.Build()
+		return nil, err
 	}
+	e.certSubject = certSubject
 
-	serverCert, err := tls.X509KeyPair(certPEM.Bytes(), certPrivKeyPEM.Bytes())
-	if err != nil {
-		e := exceptions.NewBuilder()
-		e.SetCode(exceptions.ResourcesNotPairedCode)
-		e.SetMessage(exceptions.ResourcesNotPairedMessage)
-		e.Include(exceptions.Data{Value: err.Error()})
+	return e, nil
+}
 
-		return nil, e.Build()
+//NewEngineWithTLS creates an engine serving the
+//existing certificate referenced by Config.TLS
+//instead of self-signing one
+func NewEngineWithTLS(config Config) (*engine, error) {
+	tlsConfig, err := newTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
 	}
 
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{serverCert},
+	return newEngine(tlsConfig, config)
+}
+
+func newEngine(tlsConfig *tls.Config, config Config) (*engine, error) {
+	if err := config.ClientAuth.apply(tlsConfig); err != nil {
+		return nil, err
 	}
 
 	e := engine{
@@ -188,11 +411,18 @@ func NewEngine(certSubject CertificateSubject, privateKey *rsa.PrivateKey, confi
 			Addr:      config.Service.Internal,
 			TLSConfig: tlsConfig,
 		},
-		certSubject: certSubject,
-		config:      config,
-		routes:      make(map[string]Handler),
+		config:   config,
+		routes:   newRouter(),
+		encoders: defaultEncoders(),
+		decoders: defaultDecoders(),
+		rules:    defaultRules(),
+		statuses: defaultStatuses(),
 	}
 	e.server.Handler = &e
 
+	if err := e.registerHealth(); err != nil {
+		return nil, err
+	}
+
 	return &e, nil
 }
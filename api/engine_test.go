@@ -9,6 +9,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"github.com/ravelo-systematic-solutions/fwork/exceptions"
 	"github.com/ravelo-systematic-solutions/fwork/response"
 	"github.com/ravelo-systematic-solutions/fwork/testutils"
@@ -17,6 +18,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -114,10 +116,10 @@ func TestEngine_GetHandler_success(t *testing.T) {
 	//given
 	url := "/some-url"
 	e := engine{
-		routes: make(map[string]Handler, 0),
+		routes: newRouter(),
 	}
 	key := GenerateEndpointKey(http.MethodGet, url)
-	e.routes[key] = func(s Scope) {}
+	e.routes.Add(key, func(s Scope) {})
 
 	// when
 	handler := e.GetHandler(key)
@@ -137,10 +139,10 @@ func TestEngine_GetHandler_NotFounr(t *testing.T) {
 	//given
 	url := "/some-url"
 	e := engine{
-		routes: make(map[string]Handler, 0),
+		routes: newRouter(),
 	}
 	key := GenerateEndpointKey(http.MethodGet, url)
-	e.routes[key] = func(s Scope) {}
+	e.routes.Add(key, func(s Scope) {})
 
 	// when
 	handler := e.GetHandler("invalid-key")
@@ -159,15 +161,15 @@ func TestEngine_ServeHTTP_success(t *testing.T) {
 	//given
 	url := "/some-url"
 	e := engine{
-		routes: make(map[string]Handler, 0),
+		routes: newRouter(),
 	}
 	key := GenerateEndpointKey(http.MethodGet, url)
-	e.routes[key] = func(s Scope) {
+	e.routes.Add(key, func(s Scope) {
 		s.Reply(
 			http.StatusAccepted,
 			response.Void{},
 		)
-	}
+	})
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, url, nil)
 	expectedResponse := "{}"
@@ -192,22 +194,244 @@ func TestEngine_ServeHTTP_success(t *testing.T) {
 	}
 }
 
+func TestEngine_ServeHTTP_pathValue(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+	}
+	var actual string
+	e.Controller(&Resource{
+		url: "/users/{id}",
+		routes: map[string]Handler{
+			GenerateEndpointKey(http.MethodGet, "/users/{id}"): func(s Scope) {
+				actual = s.PathValue("id")
+				s.Reply(http.StatusOK, response.Void{})
+			},
+		},
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/users/123", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusOK {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusOK,
+		)
+	}
+	if actual != "123" {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			actual,
+			"123",
+		)
+	}
+}
+
+func TestEngine_Controller_duplicated(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+	}
+	e.Controller(&Resource{
+		routes: map[string]Handler{
+			GenerateEndpointKey(http.MethodGet, "/users/{id}"): func(s Scope) {},
+		},
+	})
+
+	//when
+	err := e.Controller(&Resource{
+		routes: map[string]Handler{
+			GenerateEndpointKey(http.MethodGet, "/users/{userId}"): func(s Scope) {},
+		},
+	})
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceDuplicatedCode {
+		t.Errorf(
+			"Controller(), got %v but want %v",
+			err,
+			exceptions.ResourceDuplicatedCode,
+		)
+	}
+}
+
+func TestEngine_Controller_template(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+	}
+	users := NewResource("/Users/{userId}", Endpoints{Get: func(s Scope) {}})
+
+	//when
+	e.Controller(&users)
+
+	//then
+	if _, ok := users.Routes()[GenerateEndpointKey(http.MethodGet, "/users/{userid}")]; !ok {
+		t.Errorf("Routes(), got %v but want lowercased keys", users.Routes())
+	}
+
+	rt, _, _ := e.routes.Match(http.MethodGet, "/users/1")
+	if rt.template != "/Users/{userId}" {
+		t.Errorf("Controller(), got %v but want %v", rt.template, "/Users/{userId}")
+	}
+}
+
+func TestEngine_ServeHTTP_methods(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+		allow  string
+		body   string
+	}{
+		{"registered method", http.MethodGet, http.StatusOK, "", `{"name":"Jhonny"}`},
+		{"head served by get", http.MethodHead, http.StatusOK, "", ""},
+		{"automatic options", http.MethodOptions, http.StatusNoContent, "DELETE, GET, HEAD, OPTIONS", ""},
+		{"method not allowed", http.MethodPut, http.StatusMethodNotAllowed, "DELETE, GET, HEAD, OPTIONS", "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes: newRouter(),
+			}
+			e.Controller(&Resource{
+				routes: map[string]Handler{
+					GenerateEndpointKey(http.MethodGet, "/users/{id}"): func(s Scope) {
+						s.Reply(http.StatusOK, map[string]string{"name": "Jhonny"})
+					},
+					GenerateEndpointKey(http.MethodDelete, "/users/{id}"): func(s Scope) {},
+				},
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(tt.method, "/users/123", nil)
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf(
+					"ServeHTTP(), got %v but want %v",
+					w.Code,
+					tt.status,
+				)
+			}
+			if actual := w.Header().Get("Allow"); actual != tt.allow {
+				t.Errorf(
+					"ServeHTTP(), got %v but want %v",
+					actual,
+					tt.allow,
+				)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf(
+					"ServeHTTP(), got %v but want %v",
+					w.Body.String(),
+					tt.body,
+				)
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_notFound(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+	}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodOptions, "/users", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusNotFound {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusNotFound,
+		)
+	}
+	if actual := w.Header().Get("Allow"); actual != "" {
+		t.Errorf(
+			"ServeHTTP(), unexpected Allow header %v",
+			actual,
+		)
+	}
+}
+
+func TestEngine_ServeHTTP_headContentLength(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+	}
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users"), func(s Scope) {
+		s.Reply(http.StatusOK, map[string]string{"name": "Jhonny"})
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodHead, "/users", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if actual := w.Header().Get("Content-Length"); actual != "17" {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			actual,
+			"17",
+		)
+	}
+}
+
+//recorder is an interceptor which records
+//the order in which it has been called
+type recorder struct {
+	name   string
+	calls  *[]string
+	before error
+	after  error
+}
+
+func (r *recorder) Before(s Scope) error {
+	*r.calls = append(*r.calls, r.name+".before")
+	return r.before
+}
+
+func (r *recorder) After(s Scope) error {
+	*r.calls = append(*r.calls, r.name+".after")
+	return r.after
+}
+
 func TestEngine_ServeHTTP_Before_success(t *testing.T) {
 	//given
 	url := "/some-url"
+	calls := make([]string, 0)
 	e := engine{
-		routes: make(map[string]Handler, 0),
+		routes: newRouter(),
 	}
 	key := GenerateEndpointKey(http.MethodGet, url)
-	e.routes[key] = func(s Scope) {
+	e.routes.Add(key, func(s Scope) {
+		calls = append(calls, "handler")
 		s.Reply(
 			http.StatusAccepted,
 			response.Void{},
 		)
-	}
+	})
+	e.AddInterceptor(&recorder{name: "i1", calls: &calls})
+	e.AddInterceptor(&recorder{name: "i2", calls: &calls})
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, url, nil)
 	expectedResponse := "{}"
+	expectedCalls := []string{"i1.before", "i2.before", "handler", "i2.after", "i1.after"}
 
 	//when
 	e.ServeHTTP(w, r)
@@ -227,13 +451,374 @@ func TestEngine_ServeHTTP_Before_success(t *testing.T) {
 			http.StatusAccepted,
 		)
 	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			calls,
+			expectedCalls,
+		)
+	}
+}
+
+func TestEngine_ServeHTTP_Before_error(t *testing.T) {
+	//given
+	url := "/some-url"
+	calls := make([]string, 0)
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		calls = append(calls, "handler")
+	})
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+	e.AddInterceptor(&recorder{name: "i1", calls: &calls})
+	e.AddInterceptor(&recorder{name: "i2", calls: &calls, before: ex.Build()})
+	e.AddInterceptor(&recorder{name: "i3", calls: &calls})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	expectedResponse := `{"code":"fwork_ri","message":"resource invalid","request_id":"req-1"}`
+	expectedCalls := []string{"i1.before", "i2.before", "i1.after"}
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Body.String() != expectedResponse {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Body.String(),
+			expectedResponse,
+		)
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusBadRequest,
+		)
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			calls,
+			expectedCalls,
+		)
+	}
+}
+
+func TestEngine_ServeHTTP_Before_errorWithStatus(t *testing.T) {
+	//given
+	url := "/some-url"
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {})
+	e.AddInterceptor(&auth{})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusUnauthorized,
+		)
+	}
 }
 
-func TestEngine_ServeHTTP_Before_error(t *testing.T) {}
+//auth is an interceptor which rejects
+//every request as unauthorized
+type auth struct{}
+
+func (a *auth) Before(s Scope) error {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+	s.Reply(http.StatusUnauthorized, ex.Build())
+
+	return ex.Build()
+}
 
-func TestEngine_ServeHTTP_After_success(t *testing.T) {}
+func (a *auth) After(s Scope) error {
+	return nil
+}
 
-func TestEngine_ServeHTTP_After_error(t *testing.T) {}
+func TestEngine_ServeHTTP_After_success(t *testing.T) {
+	//given
+	url := "/some-url"
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		s.Reply(
+			http.StatusAccepted,
+			response.Void{},
+		)
+	})
+	m := &Measurement{}
+	e.AddInterceptor(m)
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	var record *Record
+	e.AddInterceptor(&spy{after: func(s Scope) {
+		record, _ = GetMeasurement(s)
+	}})
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if record == nil {
+		t.Fatalf("ServeHTTP(), measurement expected")
+	}
+	if record.Resource != url {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			record.Resource,
+			url,
+		)
+	}
+	if w.Code != http.StatusAccepted {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusAccepted,
+		)
+	}
+}
+
+//spy is an interceptor which exposes
+//the scope after the handler is called
+type spy struct {
+	after func(s Scope)
+}
+
+func (i *spy) Before(s Scope) error {
+	return nil
+}
+
+func (i *spy) After(s Scope) error {
+	i.after(s)
+	return nil
+}
+
+func TestEngine_ServeHTTP_After_error(t *testing.T) {
+	//given
+	url := "/some-url"
+	calls := make([]string, 0)
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		s.Reply(
+			http.StatusAccepted,
+			response.Void{},
+		)
+	})
+	e.AddInterceptor(&recorder{name: "i1", calls: &calls})
+	e.AddInterceptor(&recorder{name: "i2", calls: &calls, after: errors.New("failed")})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	expectedResponse := `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`
+	expectedCalls := []string{"i1.before", "i2.before", "i2.after", "i1.after"}
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusInternalServerError,
+		)
+	}
+	if w.Body.String() != expectedResponse {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Body.String(),
+			expectedResponse,
+		)
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			calls,
+			expectedCalls,
+		)
+	}
+}
+
+//panicking is an interceptor whose After panics
+type panicking struct{}
+
+func (p *panicking) Before(s Scope) error {
+	return nil
+}
+
+func (p *panicking) After(s Scope) error {
+	panic("after failed")
+}
+
+func TestEngine_ServeHTTP_After_interceptorPanic(t *testing.T) {
+	//given
+	url := "/some-url"
+	calls := make([]string, 0)
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		s.Reply(http.StatusOK, response.Void{})
+	})
+	e.AddInterceptor(&recorder{name: "i1", calls: &calls})
+	e.AddInterceptor(&panicking{})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	expectedCalls := []string{"i1.before", "i1.after"}
+	expectedResponse := `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("ServeHTTP(), got %v but want %v", calls, expectedCalls)
+	}
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusInternalServerError)
+	}
+
+	if w.Body.String() != expectedResponse {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), expectedResponse)
+	}
+}
+
+func TestEngine_ServeHTTP_After_panic(t *testing.T) {
+	//given
+	url := "/some-url"
+	calls := make([]string, 0)
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		panic("handler failed")
+	})
+	e.AddInterceptor(&recorder{name: "i1", calls: &calls})
+	e.AddInterceptor(&recorder{name: "i2", calls: &calls})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	expectedCalls := []string{"i1.before", "i2.before", "i2.after", "i1.after"}
+	expectedResponse := `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			calls,
+			expectedCalls,
+		)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Code,
+			http.StatusInternalServerError,
+		)
+	}
+	if w.Body.String() != expectedResponse {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			w.Body.String(),
+			expectedResponse,
+		)
+	}
+}
+
+func TestEngine_ServeHTTP_panic_measured(t *testing.T) {
+	//given
+	url := "/some-url"
+	var record *Record
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		record, _ = GetMeasurement(s)
+		var counts map[string]int
+		counts["calls"]++
+	})
+	e.AddInterceptor(&Measurement{})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if record == nil || record.StatusCode != http.StatusInternalServerError {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			record,
+			http.StatusInternalServerError,
+		)
+	}
+}
+
+func TestEngine_ServeHTTP_panic_abort(t *testing.T) {
+	//given
+	url := "/some-url"
+	calls := make([]string, 0)
+	e := engine{
+		routes: newRouter(),
+	}
+	key := GenerateEndpointKey(http.MethodGet, url)
+	e.routes.Add(key, func(s Scope) {
+		panic(http.ErrAbortHandler)
+	})
+	e.AddInterceptor(&recorder{name: "i1", calls: &calls})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	expectedCalls := []string{"i1.before", "i1.after"}
+
+	//when
+	var actual any
+	func() {
+		defer func() { actual = recover() }()
+		e.ServeHTTP(w, r)
+	}()
+
+	//then
+	if actual != http.ErrAbortHandler {
+		t.Errorf("ServeHTTP(), got %v but want %v", actual, http.ErrAbortHandler)
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf(
+			"ServeHTTP(), got %v but want %v",
+			calls,
+			expectedCalls,
+		)
+	}
+}
 
 func TestNewEngineService(t *testing.T) {
 	//given
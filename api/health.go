@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//HealthUrl serves the liveness of the engine
+const HealthUrl = "/healthz"
+
+//ReadyUrl serves the readiness of the engine
+const ReadyUrl = "/readyz"
+
+//DefaultHealthCheckTimeout bounds every health
+//check when Health.Timeout is not set
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+//Health configures the built-in health and
+//readiness endpoints. They are served past the
+//interceptors, so probes are neither rejected
+//(eg. by authentication) nor measured
+type Health struct {
+	//Enabled registers the endpoints
+	Enabled bool
+
+	//LivenessUrl & ReadinessUrl default
+	//to HealthUrl & ReadyUrl
+	LivenessUrl  string
+	ReadinessUrl string
+
+	//Timeout bounds every check run by the
+	//endpoints, DefaultHealthCheckTimeout if zero
+	Timeout time.Duration
+}
+
+//urls retrieves the liveness
+//and readiness urls
+func (h Health) urls() (string, string) {
+	liveness, readiness := h.LivenessUrl, h.ReadinessUrl
+	if liveness == "" {
+		liveness = HealthUrl
+	}
+
+	if readiness == "" {
+		readiness = ReadyUrl
+	}
+
+	return liveness, readiness
+}
+
+//statuses reported by health checks
+const (
+	HealthUp      = "up"
+	HealthDown    = "down"
+	HealthTimeout = "timeout"
+)
+
+//HealthCheck reports if a dependency of the
+//application (eg. a database or a downstream
+//service) is healthy. Check must return once
+//the context is done
+type HealthCheck interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c healthCheck) Name() string {
+	return c.name
+}
+
+func (c healthCheck) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+//NewHealthCheck creates a HealthCheck
+//out of the given function
+func NewHealthCheck(name string, check func(ctx context.Context) error) HealthCheck {
+	return healthCheck{
+		name:  name,
+		check: check,
+	}
+}
+
+//HealthReport is replied by the health
+//and readiness endpoints
+type HealthReport struct {
+	Id     string        `json:"id,omitempty" xml:"id,omitempty" yaml:"id,omitempty"`
+	Name   string        `json:"name,omitempty" xml:"name,omitempty" yaml:"name,omitempty"`
+	Status string        `json:"status" xml:"status" yaml:"status"`
+	Checks []CheckResult `json:"checks,omitempty" xml:"checks>check,omitempty" yaml:"checks,omitempty"`
+}
+
+//CheckResult is the outcome of a single check.
+//Errors are logged but never replied
+type CheckResult struct {
+	Name     string        `json:"name" xml:"name" yaml:"name"`
+	Status   string        `json:"status" xml:"status" yaml:"status"`
+	Duration time.Duration `json:"duration" xml:"duration" yaml:"duration"`
+}
+
+//AddLivenessCheck registers a check run by the
+//health endpoint. Liveness checks should only
+//fail if the engine needs to be restarted
+func (e *engine) AddLivenessCheck(c HealthCheck) {
+	e.liveness = append(e.liveness, c)
+}
+
+//AddReadinessCheck registers a check run by the
+//readiness endpoint, which fails while any of
+//the dependencies of the engine is unhealthy
+func (e *engine) AddReadinessCheck(c HealthCheck) {
+	e.readiness = append(e.readiness, c)
+}
+
+//registerHealth registers the built-in health and
+//readiness controllers when enabled, marking them
+//as probes so they skip the interceptors
+func (e *engine) registerHealth() error {
+	if !e.config.Health.Enabled {
+		return nil
+	}
+
+	liveness, readiness := e.config.Health.urls()
+	health := NewResource(liveness, Endpoints{Get: e.serveHealth})
+	ready := NewResource(readiness, Endpoints{Get: e.serveReadiness})
+
+	e.probes = map[string]bool{}
+	for _, c := range []Controller{&health, &ready} {
+		if err := e.Controller(c); err != nil {
+			return err
+		}
+
+		for k := range c.Routes() {
+			e.probes[k] = true
+		}
+	}
+
+	return nil
+}
+
+//isProbe reports if the scope
+//was routed to a health probe
+func (e *engine) isProbe(s *scope) bool {
+	return routedTo(s, e.probes)
+}
+
+//serveHealth replies with the outcome of the
+//liveness checks
+func (e *engine) serveHealth(s Scope) {
+	e.replyHealth(s, e.check(s.Context(), logfOf(s), e.liveness))
+}
+
+//serveReadiness replies with the outcome of the readiness
+//checks. The engine is not ready while draining
+func (e *engine) serveReadiness(s Scope) {
+	results := e.check(s.Context(), logfOf(s), e.readiness)
+
+	engine := CheckResult{Name: "engine", Status: HealthUp}
+	if !e.Ready() {
+		engine.Status = HealthDown
+	}
+	results = append([]CheckResult{engine}, results...)
+
+	e.replyHealth(s, results)
+}
+
+//replyHealth replies with 200 if every check
+//is up and 503 otherwise
+func (e *engine) replyHealth(s Scope, results []CheckResult) {
+	report := HealthReport{
+		Id:     e.config.Service.Id,
+		Name:   e.config.Service.Name,
+		Status: HealthUp,
+		Checks: results,
+	}
+
+	for _, r := range results {
+		if r.Status != HealthUp {
+			report.Status = HealthDown
+		}
+	}
+
+	if report.Status != HealthUp {
+		s.Reply(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	s.Reply(http.StatusOK, report)
+}
+
+//logfOf retrieves the logger of the scope, which
+//prefixes the messages with the request id
+func logfOf(s Scope) func(format string, v ...any) {
+	if sc, ok := s.(*scope); ok {
+		return sc.logf
+	}
+
+	return log.Printf
+}
+
+//check runs the checks concurrently, each one
+//bounded by the health check timeout
+func (e *engine) check(ctx context.Context, logf func(format string, v ...any), checks []HealthCheck) []CheckResult {
+	results := make([]CheckResult, len(checks))
+	timeout := e.healthCheckTimeout()
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c HealthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, logf, c, timeout)
+		}(i, c)
+	}
+	wg.Wait()
+
+	return results
+}
+
+//runCheck runs a single check, giving up on it once
+//the timeout is exceeded. Panicking checks are down
+func runCheck(ctx context.Context, logf func(format string, v ...any), c HealthCheck, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("panic: %v", v)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+
+	result := CheckResult{Name: c.Name(), Status: HealthUp}
+	select {
+	case err := <-done:
+		if err != nil {
+			logf("health check %s failed: %v", c.Name(), err)
+			result.Status = HealthDown
+		}
+	case <-ctx.Done():
+		logf("health check %s timed out: %v", c.Name(), ctx.Err())
+		result.Status = HealthTimeout
+	}
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+//healthCheckTimeout retrieves the
+//time given to every health check
+func (e *engine) healthCheckTimeout() time.Duration {
+	if e.config.Health.Timeout > 0 {
+		return e.config.Health.Timeout
+	}
+
+	return DefaultHealthCheckTimeout
+}
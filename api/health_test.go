@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//serveHealth serves a health request
+//and decodes the replied report
+func serveHealth(t *testing.T, e *engine, url string) (int, HealthReport) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, url, nil)
+	e.ServeHTTP(w, r)
+
+	var report HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("ServeHTTP(), got unexpected body %v", w.Body.String())
+	}
+
+	for i := range report.Checks {
+		report.Checks[i].Duration = 0
+	}
+
+	return w.Code, report
+}
+
+func TestEngine_health(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{Service: Service{Id: "id-1", Name: "users"}, Health: Health{Enabled: true}})
+	e.AddLivenessCheck(NewHealthCheck("db", func(ctx context.Context) error {
+		return nil
+	}))
+	expected := HealthReport{
+		Id:     "id-1",
+		Name:   "users",
+		Status: HealthUp,
+		Checks: []CheckResult{{Name: "db", Status: HealthUp}},
+	}
+
+	//when
+	status, report := serveHealth(t, e, HealthUrl)
+
+	//then
+	if status != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", status, http.StatusOK)
+	}
+
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("ServeHTTP(), got %v but want %v", report, expected)
+	}
+}
+
+func TestEngine_health_failure(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{Health: Health{Enabled: true, Timeout: 10 * time.Millisecond}})
+	e.AddLivenessCheck(NewHealthCheck("db", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}))
+	e.AddLivenessCheck(NewHealthCheck("billing", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	e.AddLivenessCheck(NewHealthCheck("cache", func(ctx context.Context) error {
+		return nil
+	}))
+	e.AddLivenessCheck(NewHealthCheck("queue", func(ctx context.Context) error {
+		var consumers map[string]int
+		consumers["orders"]++
+		return nil
+	}))
+	expected := HealthReport{
+		Status: HealthDown,
+		Checks: []CheckResult{
+			{Name: "db", Status: HealthDown},
+			{Name: "billing", Status: HealthTimeout},
+			{Name: "cache", Status: HealthUp},
+			{Name: "queue", Status: HealthDown},
+		},
+	}
+
+	//when
+	status, report := serveHealth(t, e, HealthUrl)
+
+	//then
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP(), got %v but want %v", status, http.StatusServiceUnavailable)
+	}
+
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("ServeHTTP(), got %v but want %v", report, expected)
+	}
+}
+
+func TestEngine_readiness(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{Health: Health{Enabled: true}})
+	e.AddReadinessCheck(NewHealthCheck("db", func(ctx context.Context) error {
+		return nil
+	}))
+	expected := HealthReport{
+		Status: HealthDown,
+		Checks: []CheckResult{
+			{Name: "engine", Status: HealthDown},
+			{Name: "db", Status: HealthUp},
+		},
+	}
+
+	//when
+	status, report := serveHealth(t, e, ReadyUrl)
+
+	//then
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP(), got %v but want %v", status, http.StatusServiceUnavailable)
+	}
+
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("ServeHTTP(), got %v but want %v", report, expected)
+	}
+
+	//when
+	e.ready = 1
+	status, _ = serveHealth(t, e, ReadyUrl)
+
+	//then
+	if status != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", status, http.StatusOK)
+	}
+}
+
+func TestEngine_check_concurrently(t *testing.T) {
+	//given
+	e := &engine{}
+	started := make(chan struct{})
+	checks := []HealthCheck{
+		NewHealthCheck("a", func(ctx context.Context) error {
+			<-started
+			return nil
+		}),
+		NewHealthCheck("b", func(ctx context.Context) error {
+			close(started)
+			return nil
+		}),
+	}
+
+	//when
+	results := e.check(context.TODO(), log.Printf, checks)
+
+	//then
+	for _, r := range results {
+		if r.Status != HealthUp {
+			t.Errorf("check(), got %v but want %v", r.Status, HealthUp)
+		}
+	}
+}
+
+func TestEngine_health_disabled(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{})
+	health := NewResource(HealthUrl, Endpoints{Get: func(s Scope) {
+		s.Reply(http.StatusOK, HealthReport{Status: "custom"})
+	}})
+
+	//when
+	err := e.Controller(&health)
+
+	//then
+	if err != nil {
+		t.Fatalf("Controller(), got unexpected error %v", err)
+	}
+
+	if _, report := serveHealth(t, e, HealthUrl); report.Status != "custom" {
+		t.Errorf("ServeHTTP(), got %v but want %v", report.Status, "custom")
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, ReadyUrl, nil)
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestEngine_health_urls(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{Health: Health{
+		Enabled:      true,
+		LivenessUrl:  "/live",
+		ReadinessUrl: "/ready",
+	}})
+	e.ready = 1
+
+	//when
+	live, _ := serveHealth(t, e, "/live")
+	ready, _ := serveHealth(t, e, "/ready")
+
+	//then
+	if live != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", live, http.StatusOK)
+	}
+
+	if ready != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", ready, http.StatusOK)
+	}
+}
+
+func TestEngine_health_interceptors(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{Health: Health{Enabled: true}})
+	e.AddInterceptor(&auth{})
+	e.ready = 1
+	users := NewResource("/users", Endpoints{Get: func(s Scope) {
+		s.Reply(http.StatusOK, HealthReport{})
+	}})
+	e.Controller(&users)
+
+	//when
+	live, _ := serveHealth(t, e, HealthUrl)
+	ready, _ := serveHealth(t, e, ReadyUrl)
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/users", nil)
+	e.ServeHTTP(w, r)
+
+	//then
+	if live != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", live, http.StatusOK)
+	}
+
+	if ready != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", ready, http.StatusOK)
+	}
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -1,50 +1,267 @@
 package api
 
 import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"strings"
 	"time"
 )
 
+//measurementKey references the Record stored
+//in the scope by the Measurement interceptor
+const measurementKey = "fwork_measurement"
+
+//InterceptorI is executed before and after
+//every request. Returning an error from
+//Before prevents the handler from being
+//called and replies with the error instead
 type InterceptorI interface {
-	Before(s *scope) error
-	After(s *scope) error
+	Before(s Scope) error
+	After(s Scope) error
 }
 
 //Interceptor can be executed before
 //and after a request with the given
 //scope
-type Interceptor func(s *scope) error
+type Interceptor func(s Scope) error
+
+//Record holds the measurements taken
+//for a single request
+type Record struct {
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Duration   time.Duration     `json:"duration"`
+	Method     string            `json:"method"`
+	Resource   string            `json:"resource"`
+	Route      string            `json:"route,omitempty"`
+	StatusCode int               `json:"status"`
+	Bytes      int               `json:"bytes"`
+	RemoteAddr string            `json:"remote_addr,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	Exception  string            `json:"exception,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
 
 //Measurement logs information about the
-//the api and its performance
+//the api and its performance. Records are
+//stored in the request scope, so a single
+//instance is safe to share across requests
 type Measurement struct {
-	start      time.Time
-	end        time.Time
-	duration   time.Duration
-	method     string
-	resource   string
-	statusCode int
+	//Logger emits the Record of every request
+	//once measured. Records are only stored
+	//in the scope if nil
+	Logger AccessLogger
+
+	//Headers lists the request headers recorded.
+	//None are recorded if empty, so secrets such
+	//as custom API keys are never logged
+	Headers []string
+
+	//Redact lists the recorded headers whose values
+	//are replaced, DefaultRedactedHeaders if nil
+	Redact []string
+
+	//Metrics aggregates every Record
+	//once measured, if not nil
+	Metrics *Metrics
+}
+
+//NewMeasurement creates a Measurement emitting every
+//Record through the given logger, recording only
+//the given request headers
+func NewMeasurement(logger AccessLogger, headers ...string) *Measurement {
+	return &Measurement{
+		Logger:  logger,
+		Headers: headers,
+	}
 }
 
 //Before gets called before the endpoint
 //gets called
-func (m *Measurement) Before(s *scope) error {
-	m.start = time.Now()
-	m.resource = s.Path()
-	m.method = s.Method()
+func (m *Measurement) Before(s Scope) error {
+	record := &Record{
+		Start:     time.Now(),
+		Resource:  s.Path(),
+		Method:    s.Method(),
+		Route:     routeOf(s),
+		RequestID: s.RequestID(),
+	}
+
+	if r := requestOf(s); r != nil {
+		record.RemoteAddr = r.RemoteAddr
+		record.UserAgent = r.UserAgent()
+		record.Headers = m.headers(r.Header)
+	}
+
+	s.OverrideData(measurementKey, record)
+	if m.Metrics != nil {
+		m.Metrics.begin(*record)
+	}
 	return nil
 }
 
 //After gets called after the endpoint
 //gets called
-func (m *Measurement) After(s *scope) error {
-	m.end = time.Now()
-	m.duration = m.end.Sub(m.start)
-	m.statusCode = s.s
+func (m *Measurement) After(s Scope) error {
+	record, err := GetMeasurement(s)
+	if err != nil {
+		return err
+	}
+
+	record.End = time.Now()
+	record.Duration = record.End.Sub(record.Start)
+	record.StatusCode = s.Status()
+	record.Bytes = bytesOf(s)
+	record.Exception = string(exceptionCodeOf(s))
+
+	if m.Metrics != nil {
+		m.Metrics.observe(*record)
+	}
+	if m.Logger != nil {
+		m.Logger.Log(*record)
+	}
+	return nil
+}
+
+//headers retrieves the recorded request headers
+//with the redacted values replaced. Returns nil
+//if no header is recorded
+func (m *Measurement) headers(h http.Header) map[string]string {
+	if len(m.Headers) == 0 {
+		return nil
+	}
+
+	redact := m.Redact
+	if redact == nil {
+		redact = DefaultRedactedHeaders
+	}
+
+	headers := make(map[string]string, len(m.Headers))
+	for _, name := range m.Headers {
+		if values := h.Values(name); len(values) > 0 {
+			headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+		}
+	}
+
+	for _, name := range redact {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := headers[name]; ok {
+			headers[name] = redacted
+		}
+	}
+
+	return headers
+}
+
+//bytesOf retrieves the length of the body the
+//scope will be replied with. Returns 0 for
+//scopes not created by the engine
+func bytesOf(s Scope) int {
+	sc, ok := s.(*scope)
+	if !ok || sc.r.Method == http.MethodHead || !bodyAllowed(sc.s) {
+		return 0
+	}
+
+	return len(sc.b)
+}
+
+//requestOf retrieves the request of the scope.
+//Returns nil for scopes not created by the engine
+func requestOf(s Scope) *http.Request {
+	if sc, ok := s.(*scope); ok {
+		return sc.r
+	}
+
 	return nil
 }
 
+//after calls the After of the interceptor, replying with
+//its error. A panic is recovered and replied as an
+//internal error, then retrieved so the response can
+//still be aborted
+func after(s *scope, i InterceptorI) (v any) {
+	defer func() {
+		if v = recover(); v != nil {
+			s.recovered(v)
+		}
+	}()
+
+	if err := i.After(s); err != nil {
+		s.replyError(err)
+	}
+
+	return nil
+}
+
+//GetMeasurement retrieves the Record taken by the
+//Measurement interceptor for the given scope. An
+//exception will be thrown if the request was
+//not measured
+func GetMeasurement(s Scope) (*Record, error) {
+	val, err := s.GetData(measurementKey)
+	if err != nil {
+		return nil, err
+	}
+
+	record, ok := val.(*Record)
+	if !ok {
+		ex := exceptions.NewBuilder()
+		ex.SetCode(exceptions.ResourceInvalidCode)
+		ex.SetMessage(exceptions.ResourceInvalidMessage)
+		ex.Include(exceptions.Data{Name: measurementKey})
+
+		return nil, ex.Build()
+	}
+
+	return record, nil
+}
+
 //AddInterceptor includes interceptor handlers
 //to request
 func (e *engine) AddInterceptor(i InterceptorI) {
 	e.i = append(e.i, i)
 }
+
+//intercept calls the handler wrapped by the registered
+//interceptors. Before calls run in registration order
+//and stop at the first error, which becomes the reply.
+//After calls run in reverse order for every interceptor
+//whose Before succeeded, even when the handler or another
+//After panics. Panics are recovered and replied as
+//internal errors before the following After calls run
+func (e *engine) intercept(s *scope, handler Handler) {
+	interceptors := e.i
+	if e.isProbe(s) {
+		interceptors = nil
+	}
+	executed := 0
+
+	defer func() {
+		v := recover()
+		if v != nil {
+			s.recovered(v)
+		}
+
+		for i := executed - 1; i >= 0; i-- {
+			if r := after(s, interceptors[i]); r == http.ErrAbortHandler {
+				v = r
+			}
+		}
+
+		//let net/http abort the response
+		if v == http.ErrAbortHandler {
+			panic(v)
+		}
+	}()
+
+	for _, i := range interceptors {
+		if err := i.Before(s); err != nil {
+			s.replyError(err)
+			return
+		}
+		executed++
+	}
+
+	handler(s)
+}
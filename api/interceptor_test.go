@@ -1,8 +1,12 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,27 +25,189 @@ func TestInterceptor(t *testing.T) {
 	m.After(s)
 
 	//then
-	if m.statusCode != http.StatusAccepted {
+	record, err := GetMeasurement(s)
+	if err != nil {
+		t.Fatalf("GetMeasurement(), got unexpected error %v", err)
+	}
+
+	if record.StatusCode != http.StatusAccepted {
 		t.Errorf(
 			"Before()|After(), got %v but want %v",
-			m.statusCode,
+			record.StatusCode,
 			http.StatusAccepted,
 		)
 	}
 
-	if m.method != method {
+	if record.Method != method {
 		t.Errorf(
 			"Before()|After(), got %v but want %v",
-			m.method,
+			record.Method,
 			method,
 		)
 	}
 
-	if m.resource != url {
+	if record.Resource != url {
 		t.Errorf(
 			"Before()|After(), got %v but want %v",
-			m.resource,
+			record.Resource,
 			url,
 		)
 	}
 }
+
+func TestInterceptor_perRequest(t *testing.T) {
+	//given
+	m := Measurement{}
+	r1, _ := http.NewRequest(http.MethodGet, "/first", nil)
+	r2, _ := http.NewRequest(http.MethodPost, "/second", nil)
+	s1 := NewScope(httptest.NewRecorder(), r1)
+	s2 := NewScope(httptest.NewRecorder(), r2)
+
+	//when
+	m.Before(s1)
+	m.Before(s2)
+
+	//then
+	record, _ := GetMeasurement(s1)
+	if record.Resource != "/first" {
+		t.Errorf(
+			"Before(), got %v but want %v",
+			record.Resource,
+			"/first",
+		)
+	}
+}
+
+func TestGetMeasurement_notMeasured(t *testing.T) {
+	//given
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	s := NewScope(httptest.NewRecorder(), r)
+
+	//when
+	_, err := GetMeasurement(s)
+
+	//then
+	if err == nil {
+		t.Errorf("GetMeasurement(), error expected")
+	}
+}
+
+func TestMeasurement_Logger(t *testing.T) {
+	//given
+	var buf bytes.Buffer
+	m := NewMeasurement(NewJSONLogger(&buf))
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set("User-Agent", "tests")
+	r.Header.Set("Authorization", "Bearer secret")
+	s := NewScope(httptest.NewRecorder(), r)
+	s.id = "req-1"
+
+	//when
+	m.Before(s)
+	s.Reply(http.StatusOK, map[string]string{"hello": "world"})
+	m.After(s)
+
+	//then
+	var actual Record
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatalf("Log(), got %v but want a JSON line", buf.String())
+	}
+
+	if actual.StatusCode != http.StatusOK {
+		t.Errorf("Log(), got %v but want %v", actual.StatusCode, http.StatusOK)
+	}
+
+	if actual.Bytes != len(`{"hello":"world"}`) {
+		t.Errorf("Log(), got %v but want %v", actual.Bytes, len(`{"hello":"world"}`))
+	}
+
+	if actual.RemoteAddr != "10.0.0.1:5000" {
+		t.Errorf("Log(), got %v but want %v", actual.RemoteAddr, "10.0.0.1:5000")
+	}
+
+	if actual.UserAgent != "tests" {
+		t.Errorf("Log(), got %v but want %v", actual.UserAgent, "tests")
+	}
+
+	if actual.RequestID != "req-1" {
+		t.Errorf("Log(), got %v but want %v", actual.RequestID, "req-1")
+	}
+
+	if actual.Headers != nil {
+		t.Errorf("Log(), got %v but want no headers", actual.Headers)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Log(), got %v but want no secret", buf.String())
+	}
+}
+
+func TestMeasurement_Headers(t *testing.T) {
+	//given
+	var buf bytes.Buffer
+	m := NewMeasurement(NewJSONLogger(&buf), "Accept", "Authorization")
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	r.Header.Set("Accept", MediaTypeJSON)
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Auth-Token", "secret")
+	s := NewScope(httptest.NewRecorder(), r)
+	expected := map[string]string{
+		"Accept":        MediaTypeJSON,
+		"Authorization": redacted,
+	}
+
+	//when
+	m.Before(s)
+	s.Reply(http.StatusOK, map[string]string{"hello": "world"})
+	m.After(s)
+
+	//then
+	var actual Record
+	json.Unmarshal(buf.Bytes(), &actual)
+	if !reflect.DeepEqual(actual.Headers, expected) {
+		t.Errorf("Log(), got %v but want %v", actual.Headers, expected)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Log(), got %v but want no secret", buf.String())
+	}
+}
+
+func TestMeasurement_Redact(t *testing.T) {
+	//given
+	var buf bytes.Buffer
+	m := NewMeasurement(NewJSONLogger(&buf), "x-api-key", "authorization")
+	m.Redact = []string{"x-api-key"}
+	r, _ := http.NewRequest(http.MethodHead, "/some-url", nil)
+	r.Header.Set("X-Api-Key", "secret")
+	r.Header.Set("Authorization", "Bearer token")
+	s := NewScope(httptest.NewRecorder(), r)
+
+	//when
+	m.Before(s)
+	s.Reply(http.StatusOK, map[string]string{"hello": "world"})
+	m.After(s)
+	m.Before(s)
+	m.After(s)
+
+	//then
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Log(), got %v but want %v", len(lines), 2)
+	}
+
+	var actual Record
+	json.Unmarshal([]byte(lines[0]), &actual)
+	if actual.Headers["X-Api-Key"] != redacted {
+		t.Errorf("Log(), got %v but want %v", actual.Headers["X-Api-Key"], redacted)
+	}
+
+	if actual.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("Log(), got %v but want %v", actual.Headers["Authorization"], "Bearer token")
+	}
+
+	if actual.Bytes != 0 {
+		t.Errorf("Log(), got %v but want %v", actual.Bytes, 0)
+	}
+}
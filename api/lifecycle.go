@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"sync/atomic"
+	"time"
+)
+
+//DefaultShutdownTimeout is the time given to in-flight
+//requests to drain when the engine is stopped by
+//a signal and Config.ShutdownTimeout is not set
+const DefaultShutdownTimeout = 15 * time.Second
+
+//StartHook gets called before the
+//engine starts accepting requests
+type StartHook func() error
+
+//ShutdownHook gets called once the engine
+//stopped accepting requests and every
+//in-flight request has been drained
+type ShutdownHook func(ctx context.Context) error
+
+//OnStart registers a hook called before the engine
+//starts accepting requests. Hooks are called in
+//registration order and the engine won't start
+//if any of them fails
+func (e *engine) OnStart(hook StartHook) {
+	e.onStart = append(e.onStart, hook)
+}
+
+//OnShutdown registers a hook called after the engine
+//has been drained. Hooks are called in reverse
+//registration order, so resources opened first
+//get closed last
+func (e *engine) OnShutdown(hook ShutdownHook) {
+	e.onShutdown = append(e.onShutdown, hook)
+}
+
+//Ready reports if the engine is accepting
+//requests. It turns false as soon as the
+//engine starts draining
+func (e *engine) Ready() bool {
+	return atomic.LoadInt32(&e.ready) == 1
+}
+
+//Shutdown reports the engine as not ready, keeps serving
+//for Config.DrainDelay, stops accepting requests, waits
+//for the in-flight ones to finish and calls the shutdown
+//hooks. The given context bounds the whole process.
+//An exception will be thrown listing every failure
+//and caused by the first one
+func (e *engine) Shutdown(ctx context.Context) error {
+	e.draining.Add(1)
+	defer e.draining.Done()
+
+	atomic.StoreInt32(&e.ready, 0)
+	if e.config.DrainDelay > 0 {
+		delay := time.NewTimer(e.config.DrainDelay)
+		select {
+		case <-delay.C:
+		case <-ctx.Done():
+			delay.Stop()
+		}
+	}
+
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceNotClosedCode)
+	ex.SetMessage(exceptions.ResourceNotClosedMessage)
+
+	var cause error
+	if err := e.server.Shutdown(ctx); err != nil {
+		cause = err
+		ex.Include(exceptions.Data{Value: err.Error()})
+	}
+
+	for i := len(e.onShutdown) - 1; i >= 0; i-- {
+		if err := e.onShutdown[i](ctx); err != nil {
+			if cause == nil {
+				cause = err
+			}
+			ex.Include(exceptions.Data{Value: err.Error()})
+		}
+	}
+
+	if ex.IsEmpty() {
+		return nil
+	}
+
+	ex.SetCause(cause)
+	return ex.Build()
+}
+
+//start calls the start hooks. An exception
+//will be thrown on the first failure
+func (e *engine) start() error {
+	for _, hook := range e.onStart {
+		if err := hook(); err != nil {
+			ex := exceptions.NewBuilder()
+			ex.SetCode(exceptions.ResourceNotStartedCode)
+			ex.SetMessage(exceptions.ResourceNotStartedMessage)
+			ex.SetCause(err)
+
+			return ex.Build()
+		}
+	}
+
+	return nil
+}
+
+//shutdownTimeout retrieves the time given to
+//in-flight requests to drain
+func (e *engine) shutdownTimeout() time.Duration {
+	if e.config.ShutdownTimeout > 0 {
+		return e.config.ShutdownTimeout
+	}
+
+	return DefaultShutdownTimeout
+}
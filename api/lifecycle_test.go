@@ -0,0 +1,298 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestEngine_Shutdown_hooks(t *testing.T) {
+	//given
+	calls := make([]string, 0)
+	e := &engine{ready: 1}
+	e.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "db")
+		return nil
+	})
+	e.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "cache")
+		return nil
+	})
+	expected := []string{"cache", "db"}
+
+	//when
+	err := e.Shutdown(context.TODO())
+
+	//then
+	if err != nil {
+		t.Errorf("Shutdown(), got unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Shutdown(), got %v but want %v", calls, expected)
+	}
+
+	if e.Ready() {
+		t.Errorf("Ready(), should be false")
+	}
+}
+
+func TestEngine_Shutdown_error(t *testing.T) {
+	//given
+	e := &engine{}
+	e.OnShutdown(func(ctx context.Context) error {
+		return errors.New("db")
+	})
+	e.OnShutdown(func(ctx context.Context) error {
+		return errors.New("cache")
+	})
+	expected := []exceptions.Data{
+		{Value: "cache"},
+		{Value: "db"},
+	}
+
+	//when
+	err := e.Shutdown(context.TODO())
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("Shutdown(), exception expected but got %v", err)
+	}
+
+	if ex.Code != exceptions.ResourceNotClosedCode {
+		t.Errorf("Shutdown(), got %v but want %v", ex.Code, exceptions.ResourceNotClosedCode)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("Shutdown(), got %v but want %v", ex.Data, expected)
+	}
+
+	if cause := errors.Unwrap(err); cause == nil || cause.Error() != "cache" {
+		t.Errorf("Shutdown(), got %v but want %v", cause, "cache")
+	}
+}
+
+func TestEngine_Run_startError(t *testing.T) {
+	//given
+	calls := 0
+	e := &engine{}
+	e.OnStart(func() error {
+		return errors.New("db unreachable")
+	})
+	e.OnStart(func() error {
+		calls++
+		return nil
+	})
+
+	//when
+	err := e.Run()
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceNotStartedCode {
+		t.Errorf("Run(), got %v but want %v", err, exceptions.ResourceNotStartedCode)
+	}
+
+	if calls != 0 {
+		t.Errorf("Run(), got %v calls but want %v", calls, 0)
+	}
+}
+
+func TestEngine_Run_listenError(t *testing.T) {
+	//given
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(), got unexpected error %v", err)
+	}
+	defer ln.Close()
+	e := &engine{
+		server: http.Server{Addr: ln.Addr().String()},
+	}
+	ready := false
+	e.OnStart(func() error {
+		ready = e.Ready()
+		return nil
+	})
+	closed := false
+	e.OnShutdown(func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	//when
+	err = e.Run()
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceClosedCode {
+		t.Fatalf("Run(), got %v but want %v", err, exceptions.ResourceClosedCode)
+	}
+
+	if _, ok := errors.Unwrap(err).(*net.OpError); !ok {
+		t.Errorf("Run(), got %v but want a listen error", errors.Unwrap(err))
+	}
+
+	if !closed {
+		t.Errorf("Run(), shutdown hooks were not called")
+	}
+
+	if ready || e.Ready() {
+		t.Errorf("Ready(), should be false")
+	}
+}
+
+func TestEngine_Run_signal(t *testing.T) {
+	//given
+	privateKey, _ := GeneratePrivateKey(1024)
+	e, _ := NewEngine(
+		CertificateSubject{
+			SerialNumber:  1,
+			CertNotBefore: time.Now(),
+			CertNotAfter:  time.Now().AddDate(0, 0, 1),
+		},
+		privateKey,
+		Config{
+			Service: Service{
+				Internal: "127.0.0.1:0",
+			},
+			ShutdownTimeout: time.Second,
+		},
+	)
+	closed := false
+	e.OnShutdown(func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	go func() {
+		for !e.Ready() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	//when
+	err := e.Run()
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceClosedCode {
+		t.Errorf("Run(), got %v but want %v", err, exceptions.ResourceClosedCode)
+	}
+
+	if !closed {
+		t.Errorf("Run(), shutdown hooks were not called")
+	}
+
+	if e.Ready() {
+		t.Errorf("Ready(), should be false")
+	}
+}
+
+func TestEngine_Run_shutdown(t *testing.T) {
+	//given
+	privateKey, _ := GeneratePrivateKey(1024)
+	e, _ := NewEngine(
+		CertificateSubject{
+			SerialNumber:  1,
+			CertNotBefore: time.Now(),
+			CertNotAfter:  time.Now().AddDate(0, 0, 1),
+		},
+		privateKey,
+		Config{
+			Service: Service{
+				Internal: "127.0.0.1:0",
+			},
+		},
+	)
+	closed := make(chan struct{})
+	e.OnShutdown(func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		close(closed)
+		return nil
+	})
+
+	go func() {
+		for !e.Ready() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		e.Shutdown(context.Background())
+	}()
+
+	//when
+	err := e.Run()
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceClosedCode {
+		t.Errorf("Run(), got %v but want %v", err, exceptions.ResourceClosedCode)
+	}
+
+	select {
+	case <-closed:
+	default:
+		t.Errorf("Run(), returned before the shutdown hooks were called")
+	}
+}
+
+func TestEngine_Shutdown_drainDelay(t *testing.T) {
+	//given
+	e, _ := newEngine(&tls.Config{}, Config{
+		Health:     Health{Enabled: true},
+		DrainDelay: 100 * time.Millisecond,
+	})
+	e.ready = 1
+	done := make(chan error, 1)
+
+	//when
+	go func() {
+		done <- e.Shutdown(context.Background())
+	}()
+	for e.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	status, _ := serveHealth(t, e, ReadyUrl)
+
+	//then
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP(), got %v but want %v", status, http.StatusServiceUnavailable)
+	}
+
+	select {
+	case <-done:
+		t.Errorf("Shutdown(), returned before the drain delay")
+	default:
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown(), got unexpected error %v", err)
+	}
+}
+
+func TestEngine_Shutdown_drainDelayCanceled(t *testing.T) {
+	//given
+	e := &engine{
+		ready:  1,
+		config: Config{DrainDelay: time.Hour},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+
+	//when
+	e.Shutdown(ctx)
+
+	//then
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown(), got %v but want the context to cut the drain delay", elapsed)
+	}
+}
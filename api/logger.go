@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+//redacted replaces the values which
+//must not be logged
+const redacted = "[REDACTED]"
+
+//DefaultRedactedHeaders are the headers whose values
+//are not logged by default, even if recorded
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+//AccessLogger emits one Record per request
+type AccessLogger interface {
+	Log(r Record)
+}
+
+//jsonLogger writes every Record
+//as a line of JSON
+type jsonLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (l *jsonLogger) Log(r Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.enc.Encode(r)
+}
+
+//NewJSONLogger creates an AccessLogger writing
+//every Record to w as a line of JSON
+func NewJSONLogger(w io.Writer) AccessLogger {
+	return &jsonLogger{
+		enc: json.NewEncoder(w),
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//MetricsUrl is the url serving the metrics
+const MetricsUrl = "/metrics"
+
+//MediaTypePrometheus is the media type of
+//the Prometheus text exposition format
+const MediaTypePrometheus = "text/plain; version=0.0.4; charset=utf-8"
+
+//unmatchedRoute labels the requests
+//which matched no url template
+const unmatchedRoute = "unmatched"
+
+//otherMethod labels the requests sent with a
+//method which is not a standard one, so clients
+//cannot grow the number of series at will
+const otherMethod = "OTHER"
+
+//DefaultBuckets are the upper bounds, in seconds,
+//of the latency histogram buckets
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+//requestLabels identify the requests
+//aggregated by a metric
+type requestLabels struct {
+	method string
+	route  string
+	status string
+}
+
+//routeLabels identify the requests in flight
+type routeLabels struct {
+	method string
+	route  string
+}
+
+//exceptionLabels identify the
+//exceptions replied
+type exceptionLabels struct {
+	method string
+	route  string
+	code   string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+//Metrics aggregates the records taken by the
+//Measurement interceptor and serves them in the
+//Prometheus text format. Requests are labeled by
+//method, url template and status class
+type Metrics struct {
+	mu         sync.Mutex
+	buckets    []float64
+	requests   map[requestLabels]uint64
+	durations  map[requestLabels]*histogram
+	inFlight   map[routeLabels]int64
+	exceptions map[exceptionLabels]uint64
+}
+
+//NewMetrics creates empty Metrics using the given
+//latency buckets, DefaultBuckets if none
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	return &Metrics{
+		buckets:    buckets,
+		requests:   make(map[requestLabels]uint64),
+		durations:  make(map[requestLabels]*histogram),
+		inFlight:   make(map[routeLabels]int64),
+		exceptions: make(map[exceptionLabels]uint64),
+	}
+}
+
+//begin counts a request in flight
+func (m *Metrics) begin(r Record) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight[routeLabels{methodLabel(r.Method), routeLabel(r.Route)}]++
+}
+
+//observe aggregates a measured request
+func (m *Metrics) observe(r Record) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	method, route := methodLabel(r.Method), routeLabel(r.Route)
+	m.inFlight[routeLabels{method, route}]--
+
+	labels := requestLabels{method, route, statusClass(r.StatusCode)}
+	m.requests[labels]++
+
+	h, ok := m.durations[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[labels] = h
+	}
+	seconds := r.Duration.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+
+	if r.Exception != "" {
+		m.exceptions[exceptionLabels{method, route, r.Exception}]++
+	}
+}
+
+//Render writes the metrics in the
+//Prometheus text exposition format
+func (m *Metrics) Render() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b bytes.Buffer
+
+	b.WriteString("# HELP fwork_http_requests_total Requests served.\n")
+	b.WriteString("# TYPE fwork_http_requests_total counter\n")
+	for _, l := range sortedRequests(m.requests) {
+		fmt.Fprintf(&b, "fwork_http_requests_total%s %d\n", l.labels(), m.requests[l])
+	}
+
+	b.WriteString("# HELP fwork_http_request_duration_seconds Latency of the requests served.\n")
+	b.WriteString("# TYPE fwork_http_request_duration_seconds histogram\n")
+	for _, l := range sortedRequests(m.requests) {
+		h := m.durations[l]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "fwork_http_request_duration_seconds_bucket%s %d\n", l.labels("le", formatFloat(bound)), h.counts[i])
+		}
+		fmt.Fprintf(&b, "fwork_http_request_duration_seconds_bucket%s %d\n", l.labels("le", "+Inf"), h.count)
+		fmt.Fprintf(&b, "fwork_http_request_duration_seconds_sum%s %s\n", l.labels(), formatFloat(h.sum))
+		fmt.Fprintf(&b, "fwork_http_request_duration_seconds_count%s %d\n", l.labels(), h.count)
+	}
+
+	b.WriteString("# HELP fwork_http_requests_in_flight Requests being served.\n")
+	b.WriteString("# TYPE fwork_http_requests_in_flight gauge\n")
+	inFlight := make([]routeLabels, 0, len(m.inFlight))
+	for l := range m.inFlight {
+		inFlight = append(inFlight, l)
+	}
+	sort.Slice(inFlight, func(i, j int) bool {
+		return inFlight[i].labels() < inFlight[j].labels()
+	})
+	for _, l := range inFlight {
+		fmt.Fprintf(&b, "fwork_http_requests_in_flight%s %d\n", l.labels(), m.inFlight[l])
+	}
+
+	b.WriteString("# HELP fwork_http_exceptions_total Exceptions replied by code.\n")
+	b.WriteString("# TYPE fwork_http_exceptions_total counter\n")
+	exceptions := make([]exceptionLabels, 0, len(m.exceptions))
+	for l := range m.exceptions {
+		exceptions = append(exceptions, l)
+	}
+	sort.Slice(exceptions, func(i, j int) bool {
+		return exceptions[i].labels() < exceptions[j].labels()
+	})
+	for _, l := range exceptions {
+		fmt.Fprintf(&b, "fwork_http_exceptions_total%s %d\n", l.labels(), m.exceptions[l])
+	}
+
+	return b.Bytes()
+}
+
+//Url serves the metrics on MetricsUrl
+func (m *Metrics) Url() string {
+	return MetricsUrl
+}
+
+//Routes retrieves the handler serving the metrics
+func (m *Metrics) Routes() map[string]Handler {
+	return map[string]Handler{
+		GenerateEndpointKey(http.MethodGet, MetricsUrl): m.Get,
+	}
+}
+
+func (m *Metrics) GetHandler(method, url string) Handler {
+	if method == http.MethodGet && url == MetricsUrl {
+		return m.Get
+	}
+
+	return NotFound
+}
+
+//repliesRaw serves the metrics whatever
+//media types the client accepts
+func (m *Metrics) repliesRaw() {}
+
+//Get replies with the metrics in the
+//Prometheus text exposition format
+func (m *Metrics) Get(s Scope) {
+	if sc, ok := s.(*scope); ok {
+		sc.replyRaw(http.StatusOK, MediaTypePrometheus, m.Render())
+		return
+	}
+
+	s.Reply(http.StatusOK, string(m.Render()))
+}
+
+func (l requestLabels) labels(extra ...string) string {
+	return labelsOf(append([]string{"method", l.method, "route", l.route, "status_class", l.status}, extra...)...)
+}
+
+func (l routeLabels) labels() string {
+	return labelsOf("method", l.method, "route", l.route)
+}
+
+func (l exceptionLabels) labels() string {
+	return labelsOf("method", l.method, "route", l.route, "code", l.code)
+}
+
+func sortedRequests(requests map[requestLabels]uint64) []requestLabels {
+	labels := make([]requestLabels, 0, len(requests))
+	for l := range requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].labels() < labels[j].labels()
+	})
+
+	return labels
+}
+
+//labelsOf renders label names and
+//values as {name="value",...}
+func labelsOf(pairs ...string) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteString(`"`)
+	}
+	b.WriteString("}")
+
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+//statusClass labels a status by
+//its class (eg. 404 as "4xx")
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+//methodLabel labels a method, or OTHER
+//if it is not a standard one
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost,
+		http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+
+	return otherMethod
+}
+
+func routeLabel(route string) string {
+	if route == "" {
+		return unmatchedRoute
+	}
+
+	return route
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"github.com/ravelo-systematic-solutions/fwork/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	//given
+	metrics := NewMetrics(60)
+	e := engine{
+		routes:   newRouter(),
+		encoders: defaultEncoders(),
+	}
+	e.AddInterceptor(&Measurement{Metrics: metrics})
+	e.Controller(metrics)
+	var inFlight string
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {
+		inFlight = string(metrics.Render())
+		if s.PathValue("id") == "2" {
+			ex := exceptions.NewBuilder()
+			ex.SetCode(exceptions.ResourceNotFoundCode)
+			ex.SetMessage(exceptions.ResourceNotFoundMessage)
+			s.Fail(ex.Build())
+			return
+		}
+		s.Reply(http.StatusOK, response.Void{})
+	})
+	for _, url := range []string{"/users/1", "/users/1", "/users/2", "/unknown/3"} {
+		r, _ := http.NewRequest(http.MethodGet, url, nil)
+		e.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, MetricsUrl, nil)
+	r.Header.Set("Accept", "application/openmetrics-text;version=1.0.0;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+	expected := []string{
+		`fwork_http_requests_total{method="GET",route="/users/{id}",status_class="2xx"} 2`,
+		`fwork_http_requests_total{method="GET",route="/users/{id}",status_class="4xx"} 1`,
+		`fwork_http_requests_total{method="GET",route="unmatched",status_class="4xx"} 1`,
+		`fwork_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status_class="2xx",le="60"} 2`,
+		`fwork_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status_class="2xx",le="+Inf"} 2`,
+		`fwork_http_request_duration_seconds_count{method="GET",route="/users/{id}",status_class="4xx"} 1`,
+		`fwork_http_requests_in_flight{method="GET",route="/users/{id}"} 0`,
+		`fwork_http_requests_in_flight{method="GET",route="/metrics"} 1`,
+		`fwork_http_exceptions_total{method="GET",route="/users/{id}",code="fwork_rnf"} 1`,
+		"# TYPE fwork_http_request_duration_seconds histogram",
+	}
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusOK {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusOK)
+	}
+
+	if w.Header().Get("Content-Type") != MediaTypePrometheus {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Header().Get("Content-Type"), MediaTypePrometheus)
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), line)
+		}
+	}
+
+	if !strings.Contains(inFlight, `fwork_http_requests_in_flight{method="GET",route="/users/{id}"} 1`) {
+		t.Errorf("Render(), got %v but want the request in flight", inFlight)
+	}
+}
+
+func TestMetrics_observe(t *testing.T) {
+	//given
+	metrics := NewMetrics(0.1, 0.5)
+	record := Record{
+		Method:     http.MethodPost,
+		Route:      `/a"b`,
+		StatusCode: http.StatusCreated,
+		Duration:   300 * time.Millisecond,
+	}
+	expected := []string{
+		`fwork_http_request_duration_seconds_bucket{method="POST",route="/a\"b",status_class="2xx",le="0.1"} 0`,
+		`fwork_http_request_duration_seconds_bucket{method="POST",route="/a\"b",status_class="2xx",le="0.5"} 1`,
+		`fwork_http_request_duration_seconds_sum{method="POST",route="/a\"b",status_class="2xx"} 0.3`,
+	}
+
+	//when
+	metrics.begin(record)
+	metrics.observe(record)
+
+	//then
+	actual := string(metrics.Render())
+	for _, line := range expected {
+		if !strings.Contains(actual, line+"\n") {
+			t.Errorf("Render(), got %v but want %v", actual, line)
+		}
+	}
+}
+
+func TestMetrics_accept(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		status int
+	}{
+		{"text", MetricsUrl, "text/plain", http.StatusOK},
+		{"prometheus text", MetricsUrl, "text/plain;version=0.0.4", http.StatusOK},
+		{"openmetrics", MetricsUrl, "application/openmetrics-text", http.StatusOK},
+		{"other routes", "/users", "text/plain", http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes:   newRouter(),
+				encoders: defaultEncoders(),
+			}
+			e.Controller(NewMetrics())
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users"), func(s Scope) {
+				s.Reply(http.StatusOK, response.Void{})
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			r.Header.Set("Accept", tt.accept)
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, tt.status)
+			}
+
+			if tt.status == http.StatusOK && w.Header().Get("Content-Type") != MediaTypePrometheus {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Header().Get("Content-Type"), MediaTypePrometheus)
+			}
+		})
+	}
+}
+
+func TestMetrics_methods(t *testing.T) {
+	//given
+	metrics := NewMetrics()
+	expected := []string{
+		`fwork_http_requests_total{method="GET",route="/users",status_class="2xx"} 1`,
+		`fwork_http_requests_total{method="OTHER",route="/users",status_class="4xx"} 3`,
+		`fwork_http_requests_in_flight{method="OTHER",route="/users"} 0`,
+	}
+
+	//when
+	for _, method := range []string{http.MethodGet, "PURGE", "FOO", "get"} {
+		status := http.StatusMethodNotAllowed
+		if method == http.MethodGet {
+			status = http.StatusOK
+		}
+		record := Record{Method: method, Route: "/users", StatusCode: status}
+		metrics.begin(record)
+		metrics.observe(record)
+	}
+
+	//then
+	actual := string(metrics.Render())
+	for _, line := range expected {
+		if !strings.Contains(actual, line+"\n") {
+			t.Errorf("Render(), got %v but want %v", actual, line)
+		}
+	}
+
+	if strings.Contains(actual, "PURGE") || strings.Contains(actual, "FOO") {
+		t.Errorf("Render(), got %v but want non-standard methods as OTHER", actual)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"crypto/x509"
+	"net"
+	"net/http"
+)
+
+//Peer holds the identity of a client
+//authenticated through mutual TLS
+type Peer struct {
+	CommonName     string
+	Organization   []string
+	DNSNames       []string
+	IPAddresses    []net.IP
+	EmailAddresses []string
+	URIs           []string
+	SerialNumber   string
+}
+
+//newPeer extracts the identity from the verified client
+//certificate of the request. Returns nil if the client
+//did not send a certificate or it was not verified
+func newPeer(r *http.Request) *Peer {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+
+	chain := r.TLS.VerifiedChains[0]
+	if len(chain) == 0 {
+		return nil
+	}
+
+	return peerFromCertificate(chain[0])
+}
+
+func peerFromCertificate(cert *x509.Certificate) *Peer {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	return &Peer{
+		CommonName:     cert.Subject.CommonName,
+		Organization:   cert.Subject.Organization,
+		DNSNames:       cert.DNSNames,
+		IPAddresses:    cert.IPAddresses,
+		EmailAddresses: cert.EmailAddresses,
+		URIs:           uris,
+		SerialNumber:   cert.SerialNumber.String(),
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestScope_Peer(t *testing.T) {
+	//given
+	uri, _ := url.Parse("spiffe://cluster/ns/default/sa/billing")
+	cert := &x509.Certificate{
+		SerialNumber:   big.NewInt(1234),
+		Subject:        pkix.Name{CommonName: "billing", Organization: []string{"o1"}},
+		DNSNames:       []string{"billing.internal"},
+		IPAddresses:    []net.IP{net.IPv4(10, 0, 0, 1)},
+		EmailAddresses: []string{"billing@company.com"},
+		URIs:           []*url.URL{uri},
+	}
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{cert}},
+	}
+	s := scope{r: r}
+	expected := &Peer{
+		CommonName:     "billing",
+		Organization:   []string{"o1"},
+		DNSNames:       []string{"billing.internal"},
+		IPAddresses:    []net.IP{net.IPv4(10, 0, 0, 1)},
+		EmailAddresses: []string{"billing@company.com"},
+		URIs:           []string{"spiffe://cluster/ns/default/sa/billing"},
+		SerialNumber:   "1234",
+	}
+
+	//when
+	actual := s.Peer()
+
+	//then
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Peer(), got %v but want %v", actual, expected)
+	}
+}
+
+func TestScope_Peer_unverified(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *tls.ConnectionState
+	}{
+		{"plain connection", nil},
+		{"no client certificate", &tls.ConnectionState{}},
+		{"unverified certificate", &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{SerialNumber: big.NewInt(1)}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+			r.TLS = tt.state
+			s := scope{r: r}
+
+			if actual := s.Peer(); actual != nil {
+				t.Errorf("Peer(), got %v but want nil", actual)
+			}
+		})
+	}
+}
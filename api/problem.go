@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"strings"
+)
+
+const (
+	MediaTypeProblemJSON = "application/problem+json"
+	MediaTypeProblemXML  = "application/problem+xml"
+)
+
+//blankProblemType is the problem type when it has
+//no further semantics than the status (RFC 7807 4.2)
+const blankProblemType = "about:blank"
+
+//Problems configures the rendering of exceptions
+//as RFC 7807 problem details
+type Problems struct {
+	//Enabled replies exceptions as problem details
+	//instead of the {code,message,data} shape
+	Enabled bool
+
+	//TypeURI prefixes the exception code to
+	//build the problem type (eg. "https://
+	//example.com/problems/"). The type is
+	//"about:blank" if empty
+	TypeURI string
+}
+
+//Problem is the RFC 7807 representation of an
+//exception. The exception code, data and request
+//identifier are included in the "code", "errors"
+//and "request_id" extension members
+type Problem struct {
+	XMLName   xml.Name          `json:"-" yaml:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type      string            `json:"type" xml:"type" yaml:"type"`
+	Title     string            `json:"title" xml:"title" yaml:"title"`
+	Status    int               `json:"status" xml:"status" yaml:"status"`
+	Detail    string            `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty" xml:"instance,omitempty" yaml:"instance,omitempty"`
+	Code      exceptions.Code   `json:"code,omitempty" xml:"code,omitempty" yaml:"code,omitempty"`
+	Errors    []exceptions.Data `json:"errors,omitempty" xml:"errors>error,omitempty" yaml:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty" xml:"request_id,omitempty" yaml:"request_id,omitempty"`
+}
+
+//problem renders the exception replied with the
+//given status for the request of the scope
+func (p Problems) problem(ex *exceptions.Exception, status int, instance string) Problem {
+	return Problem{
+		Type:      p.typeOf(ex.Code),
+		Title:     string(ex.Message),
+		Status:    status,
+		Detail:    detailOf(ex.Data),
+		Instance:  instance,
+		Code:      ex.Code,
+		Errors:    ex.Data,
+		RequestID: ex.RequestID,
+	}
+}
+
+//typeOf retrieves the problem type of the exception
+//code, which must be a URI reference, so the code
+//alone is never used
+func (p Problems) typeOf(code exceptions.Code) string {
+	if p.TypeURI == "" {
+		return blankProblemType
+	}
+
+	return p.TypeURI + string(code)
+}
+
+//mediaType retrieves the problem media type
+//matching the negotiated one. Returns false
+//if problems have no such representation
+func (p Problems) mediaType(negotiated string) (string, bool) {
+	switch negotiated {
+	case MediaTypeJSON:
+		return MediaTypeProblemJSON, true
+	case MediaTypeXML:
+		return MediaTypeProblemXML, true
+	}
+
+	return "", false
+}
+
+//accept extends the Accept header with the media types
+//problems are rendered from, so clients which only
+//accept problem details (eg. application/problem+json)
+//are not replied with 406 Not Acceptable. Media types
+//already listed by the client keep their quality
+func (p Problems) accept(accept string) string {
+	if !p.Enabled {
+		return accept
+	}
+
+	ranges := parseAccept(accept)
+	listed := make(map[string]bool, len(ranges))
+	for _, r := range ranges {
+		listed[r.mediaType] = true
+	}
+
+	for _, r := range ranges {
+		negotiated, ok := p.negotiatedOf(r.mediaType)
+		if !ok || listed[negotiated] {
+			continue
+		}
+
+		listed[negotiated] = true
+		accept += fmt.Sprintf(", %s;q=%v", negotiated, r.q)
+	}
+
+	return accept
+}
+
+//negotiatedOf retrieves the media type problems
+//of the given media type are rendered from
+func (p Problems) negotiatedOf(problem string) (string, bool) {
+	switch problem {
+	case MediaTypeProblemJSON:
+		return MediaTypeJSON, true
+	case MediaTypeProblemXML:
+		return MediaTypeXML, true
+	}
+
+	return "", false
+}
+
+//detailOf explains which values caused the
+//exception. Returns an empty string if
+//the values are not named
+func detailOf(data []exceptions.Data) string {
+	names := make([]string, 0, len(data))
+	for _, d := range data {
+		if d.Name != "" && !contains(names, d.Name) {
+			names = append(names, d.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	return "invalid values: " + strings.Join(names, ", ")
+}
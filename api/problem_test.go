@@ -0,0 +1,153 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEngine_ServeHTTP_problems(t *testing.T) {
+	tests := []struct {
+		name        string
+		problems    Problems
+		accept      string
+		contentType string
+		response    string
+	}{
+		{
+			name:        "disabled",
+			problems:    Problems{},
+			accept:      MediaTypeJSON,
+			contentType: MediaTypeJSON,
+			response:    `{"code":"fwork_ri","message":"resource invalid","data":[{"name":"id","tag":"min=1","value":0}],"request_id":"req-1"}`,
+		},
+		{
+			name:        "json",
+			problems:    Problems{Enabled: true, TypeURI: "https://example.com/problems/"},
+			accept:      MediaTypeJSON,
+			contentType: MediaTypeProblemJSON,
+			response:    `{"type":"https://example.com/problems/fwork_ri","title":"resource invalid","status":400,"detail":"invalid values: id","instance":"/users/0?full=true","code":"fwork_ri","errors":[{"name":"id","tag":"min=1","value":0}],"request_id":"req-1"}`,
+		},
+		{
+			name:        "xml",
+			problems:    Problems{Enabled: true},
+			accept:      MediaTypeXML,
+			contentType: MediaTypeProblemXML,
+			response:    `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>resource invalid</title><status>400</status><detail>invalid values: id</detail><instance>/users/0?full=true</instance><code>fwork_ri</code><errors><error><name>id</name><tag>min=1</tag><value>0</value></error></errors><request_id>req-1</request_id></problem>`,
+		},
+		{
+			name:        "json without type uri",
+			problems:    Problems{Enabled: true},
+			accept:      MediaTypeJSON,
+			contentType: MediaTypeProblemJSON,
+			response:    `{"type":"about:blank","title":"resource invalid","status":400,"detail":"invalid values: id","instance":"/users/0?full=true","code":"fwork_ri","errors":[{"name":"id","tag":"min=1","value":0}],"request_id":"req-1"}`,
+		},
+		{
+			name:        "accepting problem json",
+			problems:    Problems{Enabled: true},
+			accept:      MediaTypeProblemJSON,
+			contentType: MediaTypeProblemJSON,
+			response:    `{"type":"about:blank","title":"resource invalid","status":400,"detail":"invalid values: id","instance":"/users/0?full=true","code":"fwork_ri","errors":[{"name":"id","tag":"min=1","value":0}],"request_id":"req-1"}`,
+		},
+		{
+			name:        "accepting problem xml",
+			problems:    Problems{Enabled: true},
+			accept:      MediaTypeProblemXML + ";q=0.9, " + MediaTypeYAML + ";q=0.5",
+			contentType: MediaTypeProblemXML,
+			response:    `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>resource invalid</title><status>400</status><detail>invalid values: id</detail><instance>/users/0?full=true</instance><code>fwork_ri</code><errors><error><name>id</name><tag>min=1</tag><value>0</value></error></errors><request_id>req-1</request_id></problem>`,
+		},
+		{
+			name:        "no problem representation",
+			problems:    Problems{Enabled: true},
+			accept:      MediaTypeYAML,
+			contentType: MediaTypeYAML,
+			response:    "code: fwork_ri\nmessage: resource invalid\ndata:\n    - name: id\n      tag: min=1\n      value: 0\nrequest_id: req-1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes:   newRouter(),
+				encoders: defaultEncoders(),
+				config:   Config{Problems: tt.problems},
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {
+				ex := exceptions.NewBuilder()
+				ex.SetCode(exceptions.ResourceInvalidCode)
+				ex.SetMessage(exceptions.ResourceInvalidMessage)
+				ex.Include(exceptions.Data{Name: "id", Tag: "min=1", Value: 0})
+				s.Fail(ex.Build())
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/users/0?full=true", nil)
+			r.Header.Set("Accept", tt.accept)
+			r.Header.Set(RequestIDHeader, "req-1")
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusBadRequest)
+			}
+
+			if w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Header().Get("Content-Type"), tt.contentType)
+			}
+
+			if w.Body.String() != tt.response {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), tt.response)
+			}
+		})
+	}
+}
+
+func TestProblems_accept(t *testing.T) {
+	tests := []struct {
+		name     string
+		problems Problems
+		accept   string
+		want     string
+	}{
+		{"disabled", Problems{}, MediaTypeProblemJSON, MediaTypeProblemJSON},
+		{"problem json", Problems{Enabled: true}, MediaTypeProblemJSON, MediaTypeProblemJSON + ", " + MediaTypeJSON + ";q=1"},
+		{"problem xml", Problems{Enabled: true}, MediaTypeProblemXML + ";q=0.5", MediaTypeProblemXML + ";q=0.5, " + MediaTypeXML + ";q=0.5"},
+		{"already listed", Problems{Enabled: true}, MediaTypeProblemJSON + ", " + MediaTypeJSON + ";q=0", MediaTypeProblemJSON + ", " + MediaTypeJSON + ";q=0"},
+		{"other types", Problems{Enabled: true}, MediaTypeYAML, MediaTypeYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			actual := tt.problems.accept(tt.accept)
+
+			//then
+			if actual != tt.want {
+				t.Errorf("accept(), got %v but want %v", actual, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_problemsNotAcceptable(t *testing.T) {
+	//given
+	e := engine{
+		routes:   newRouter(),
+		encoders: defaultEncoders(),
+	}
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {
+		s.Reply(http.StatusOK, map[string]string{"id": s.PathValue("id")})
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+	r.Header.Set("Accept", MediaTypeProblemJSON)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusNotAcceptable)
+	}
+}
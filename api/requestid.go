@@ -0,0 +1,68 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+//RequestIDHeader carries the identifier
+//correlating a request across services
+const RequestIDHeader = "X-Request-ID"
+
+//maxRequestIDLength bounds the length of
+//the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+//requestID retrieves the identifier of the request,
+//which is the X-Request-ID header or the trace ID of
+//the traceparent header if valid. A random one is
+//generated otherwise
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+
+	if sc, ok := parseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+		return sc.TraceID
+	}
+
+	return newRequestID()
+}
+
+//validRequestID reports if the identifier is short
+//and only made of visible ASCII characters, so
+//it can be safely echoed and logged
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+//newRequestID generates a random identifier
+func newRequestID() string {
+	return randomHex(16)
+}
+
+//randomHex generates n random bytes
+//encoded as hexadecimal
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+//RequestID retrieves the identifier correlating
+//the request across services
+func (s *scope) RequestID() string {
+	return s.id
+}
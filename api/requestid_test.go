@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEngine_ServeHTTP_requestID(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+		expected    string
+	}{
+		{"from header", "req-1", "", "req-1"},
+		{"from traceparent", "", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"header over traceparent", "req-1", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "req-1"},
+		{"invalid header", "req 1\n", "", ""},
+		{"too long header", strings.Repeat("a", maxRequestIDLength+1), "", ""},
+		{"invalid traceparent", "", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"generated", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			url := "/some-url"
+			var actual string
+			e := engine{
+				routes: newRouter(),
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, url), func(s Scope) {
+				actual = s.RequestID()
+				s.Reply(http.StatusNoContent, nil)
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, url, nil)
+			if tt.requestID != "" {
+				r.Header[http.CanonicalHeaderKey(RequestIDHeader)] = []string{tt.requestID}
+			}
+			if tt.traceparent != "" {
+				r.Header.Set(TraceParentHeader, tt.traceparent)
+			}
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if tt.expected != "" && actual != tt.expected {
+				t.Errorf("RequestID(), got %v but want %v", actual, tt.expected)
+			}
+
+			if tt.expected == "" && (len(actual) != 32 || !isHex(actual)) {
+				t.Errorf("RequestID(), got %v but want a generated one", actual)
+			}
+
+			if w.Header().Get(RequestIDHeader) != actual {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Header().Get(RequestIDHeader), actual)
+			}
+		})
+	}
+}
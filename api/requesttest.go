@@ -3,17 +3,21 @@ package api
 import (
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 type Request interface {
 	QueryValue(k, v string)
 	EncodedQuery() string
 	HeaderValue(k, v string)
+	PathValue(k, v string)
+	PathValues() map[string]string
 }
 
 type request struct {
 	query   *url.Values
 	headers *http.Header
+	path    map[string]string
 	body    interface{}
 }
 
@@ -29,9 +33,18 @@ func (r *request) HeaderValue(k, v string) {
 	r.headers.Set(k, v)
 }
 
+func (r *request) PathValue(k, v string) {
+	r.path[strings.ToLower(k)] = v
+}
+
+func (r *request) PathValues() map[string]string {
+	return r.path
+}
+
 func NewTestRequest() *request {
 	return &request{
 		query:   &url.Values{},
 		headers: &http.Header{},
+		path:    make(map[string]string),
 	}
 }
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//wildcardSegment matches the rest of the
+//path without naming it
+const wildcardSegment = "*"
+
+//wildcardSuffix marks a named parameter
+//matching the rest of the path (eg. {path...})
+const wildcardSuffix = "..."
+
+//route holds a handler registered for a
+//method and the names of the parameters
+//declared by its url template
+type route struct {
+	template string
+	params   []string
+	handler  Handler
+}
+
+//node is a single path segment of the
+//registered url templates
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	routes   map[string]route
+}
+
+//router matches request paths against url
+//templates such as /users/{id}. Static segments
+//win over parameters, which win over wildcards
+type router struct {
+	root *node
+}
+
+//Add registers a handler using a key generated
+//by GenerateEndpointKey. Static segments and
+//parameter names are matched regardless of
+//their case. An exception will be thrown if
+//the key is invalid or if a handler is already
+//registered for the same method and url template
+func (r *router) Add(key string, h Handler) error {
+	return r.addTemplate(key, "", h)
+}
+
+//addTemplate registers a handler like Add, keeping
+//the given template (eg. the url of a Resource) as
+//the template of the route if it only differs from
+//the url of the key by its case
+func (r *router) addTemplate(key, display string, h Handler) error {
+	method, template, ok := strings.Cut(key, "-")
+	if !ok {
+		return routeException(exceptions.ResourceInvalidCode, exceptions.ResourceInvalidMessage, key)
+	}
+	method = strings.ToLower(method)
+
+	if strings.EqualFold(display, template) {
+		template = display
+	}
+
+	n := r.root
+	params := make([]string, 0)
+	segments := splitPath(template)
+
+	for i, segment := range segments {
+		name, kind := parseSegment(segment)
+		name = strings.ToLower(name)
+
+		switch kind {
+		case segmentWildcard:
+			if i != len(segments)-1 {
+				return routeException(exceptions.ResourceInvalidCode, exceptions.ResourceInvalidMessage, key)
+			}
+			if n.wildcard == nil {
+				n.wildcard = newNode()
+			}
+			n = n.wildcard
+			params = append(params, name)
+		case segmentParam:
+			if n.param == nil {
+				n.param = newNode()
+			}
+			n = n.param
+			params = append(params, name)
+		default:
+			child, ok := n.static[name]
+			if !ok {
+				child = newNode()
+				n.static[name] = child
+			}
+			n = child
+		}
+	}
+
+	if _, ok := n.routes[method]; ok {
+		return routeException(exceptions.ResourceDuplicatedCode, exceptions.ResourceDuplicatedMessage, key)
+	}
+
+	n.routes[method] = route{
+		template: template,
+		params:   params,
+		handler:  h,
+	}
+
+	return nil
+}
+
+//Match retrieves the route registered for the
+//given method and path along with the values
+//of its parameters
+func (r *router) Match(method, path string) (route, map[string]string, bool) {
+	method = strings.ToLower(method)
+	values := make([]string, 0)
+	n := r.root.find(method, splitPath(path), &values)
+	if n == nil {
+		return route{}, nil, false
+	}
+
+	rt := n.routes[method]
+
+	params := make(map[string]string, len(rt.params))
+	for i, name := range rt.params {
+		params[name] = values[i]
+	}
+
+	return rt, params, true
+}
+
+//Allowed retrieves the methods registered for
+//every url template matching the given path
+func (r *router) Allowed(path string) []string {
+	nodes := make([]*node, 0)
+	r.root.collect(splitPath(path), &nodes)
+
+	methods := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		for method := range n.routes {
+			if !seen[method] {
+				seen[method] = true
+				methods = append(methods, strings.ToUpper(method))
+			}
+		}
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+//collect gathers every node matching
+//the segments regardless of the method
+func (n *node) collect(segments []string, nodes *[]*node) {
+	if len(segments) == 0 {
+		if len(n.routes) > 0 {
+			*nodes = append(*nodes, n)
+		}
+		if n.wildcard != nil && len(n.wildcard.routes) > 0 {
+			*nodes = append(*nodes, n.wildcard)
+		}
+		return
+	}
+
+	segment, err := url.PathUnescape(segments[0])
+	if err != nil {
+		segment = segments[0]
+	}
+
+	if child, ok := n.static[strings.ToLower(segment)]; ok {
+		child.collect(segments[1:], nodes)
+	}
+
+	if n.param != nil && segment != "" {
+		n.param.collect(segments[1:], nodes)
+	}
+
+	if n.wildcard != nil && len(n.wildcard.routes) > 0 {
+		*nodes = append(*nodes, n.wildcard)
+	}
+}
+
+//handles reports if the node has a route for the
+//given method. An empty method matches any route
+func (n *node) handles(method string) bool {
+	if method == "" {
+		return len(n.routes) > 0
+	}
+
+	_, ok := n.routes[method]
+	return ok
+}
+
+//find walks the tree looking for the node matching
+//every segment which handles the given method,
+//backtracking when a more specific branch
+//leads nowhere
+func (n *node) find(method string, segments []string, values *[]string) *node {
+	if len(segments) == 0 {
+		if n.handles(method) {
+			return n
+		}
+		if n.wildcard != nil && n.wildcard.handles(method) {
+			*values = append(*values, "")
+			return n.wildcard
+		}
+		return nil
+	}
+
+	segment, err := url.PathUnescape(segments[0])
+	if err != nil {
+		segment = segments[0]
+	}
+
+	if child, ok := n.static[strings.ToLower(segment)]; ok {
+		if found := child.find(method, segments[1:], values); found != nil {
+			return found
+		}
+	}
+
+	if n.param != nil && segment != "" {
+		*values = append(*values, segment)
+		if found := n.param.find(method, segments[1:], values); found != nil {
+			return found
+		}
+		*values = (*values)[:len(*values)-1]
+	}
+
+	if n.wildcard != nil && n.wildcard.handles(method) {
+		rest, err := url.PathUnescape(strings.Join(segments, "/"))
+		if err != nil {
+			rest = strings.Join(segments, "/")
+		}
+		*values = append(*values, rest)
+		return n.wildcard
+	}
+
+	return nil
+}
+
+type segmentKind int
+
+const (
+	segmentStatic segmentKind = iota
+	segmentParam
+	segmentWildcard
+)
+
+//parseSegment retrieves the parameter name
+//declared by a url template segment
+func parseSegment(segment string) (string, segmentKind) {
+	if segment == wildcardSegment {
+		return wildcardSegment, segmentWildcard
+	}
+
+	if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		return segment, segmentStatic
+	}
+
+	name := segment[1 : len(segment)-1]
+	if strings.HasSuffix(name, wildcardSuffix) {
+		return strings.TrimSuffix(name, wildcardSuffix), segmentWildcard
+	}
+
+	return name, segmentParam
+}
+
+//splitPath splits a path into its segments
+//ignoring the leading slash
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
+
+func routeException(code exceptions.Code, message exceptions.Message, key string) error {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(code)
+	ex.SetMessage(message)
+	ex.Include(exceptions.Data{Value: key})
+
+	return ex.Build()
+}
+
+func newNode() *node {
+	return &node{
+		static: make(map[string]*node),
+		routes: make(map[string]route),
+	}
+}
+
+//newRouter creates an empty router
+func newRouter() *router {
+	return &router{
+		root: newNode(),
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouter_Match(t *testing.T) {
+	templates := []string{
+		"/users",
+		"/users/me",
+		"/users/{id}",
+		"/users/{id}/orders/{orderId}",
+		"/files/{path...}",
+		"/static/*",
+	}
+	tests := []struct {
+		name     string
+		path     string
+		template string
+		params   map[string]string
+	}{
+		{"static route", "/users", "/users", map[string]string{}},
+		{"static wins over param", "/users/me", "/users/me", map[string]string{}},
+		{"single param", "/users/123", "/users/{id}", map[string]string{"id": "123"}},
+		{"param keeps case", "/users/AbC", "/users/{id}", map[string]string{"id": "AbC"}},
+		{"escaped param", "/users/a%2Fb", "/users/{id}", map[string]string{"id": "a/b"}},
+		{"nested params", "/users/1/orders/2", "/users/{id}/orders/{orderId}", map[string]string{"id": "1", "orderid": "2"}},
+		{"named wildcard", "/files/a/b/c.txt", "/files/{path...}", map[string]string{"path": "a/b/c.txt"}},
+		{"empty wildcard", "/files", "/files/{path...}", map[string]string{"path": ""}},
+		{"unnamed wildcard", "/static/css/app.css", "/static/*", map[string]string{"*": "css/app.css"}},
+	}
+	r := newRouter()
+	for _, template := range templates {
+		if err := r.Add(GenerateEndpointKey(http.MethodGet, template), func(s Scope) {}); err != nil {
+			t.Fatalf("Add(), got unexpected error %v", err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, params, ok := r.Match(http.MethodGet, tt.path)
+
+			if !ok {
+				t.Fatalf("Match(), no route found for %v", tt.path)
+			}
+
+			if rt.template != strings.ToLower(tt.template) {
+				t.Errorf("Match(), got %v but want %v", rt.template, tt.template)
+			}
+
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("Match(), got %v but want %v", params, tt.params)
+			}
+		})
+	}
+}
+
+func TestRouter_Match_notFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"unknown path", http.MethodGet, "/orders"},
+		{"too many segments", http.MethodGet, "/users/1/2"},
+		{"empty param", http.MethodGet, "/users/"},
+		{"unknown method", http.MethodPost, "/users/1"},
+	}
+	r := newRouter()
+	r.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, ok := r.Match(tt.method, tt.path); ok {
+				t.Errorf("Match(), unexpected route for %v %v", tt.method, tt.path)
+			}
+		})
+	}
+}
+
+func TestRouter_Match_backtracking(t *testing.T) {
+	//given
+	r := newRouter()
+	r.Add(GenerateEndpointKey(http.MethodGet, "/users/me"), func(s Scope) {})
+	r.Add(GenerateEndpointKey(http.MethodDelete, "/users/{id}"), func(s Scope) {})
+
+	//when
+	rt, params, ok := r.Match(http.MethodDelete, "/users/me")
+
+	//then
+	if !ok || rt.template != "/users/{id}" {
+		t.Fatalf("Match(), got %v but want %v", rt.template, "/users/{id}")
+	}
+
+	if params["id"] != "me" {
+		t.Errorf("Match(), got %v but want %v", params["id"], "me")
+	}
+}
+
+func TestRouter_Match_case(t *testing.T) {
+	//given
+	r := newRouter()
+	r.addTemplate(GenerateEndpointKey(http.MethodGet, "/Users/{userId}"), "/Users/{userId}", func(s Scope) {})
+	expected := map[string]string{"userid": "AbC"}
+
+	//when
+	rt, params, ok := r.Match(http.MethodGet, "/users/AbC")
+
+	//then
+	if !ok || rt.template != "/Users/{userId}" {
+		t.Fatalf("Match(), got %v but want %v", rt.template, "/Users/{userId}")
+	}
+
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Match(), got %v but want %v", params, expected)
+	}
+}
+
+func TestRouter_Add_duplicated(t *testing.T) {
+	//given
+	r := newRouter()
+	r.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {})
+
+	//when
+	err := r.Add(GenerateEndpointKey(http.MethodGet, "/users/{userId}"), func(s Scope) {})
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceDuplicatedCode {
+		t.Errorf("Add(), got %v but want %v", err, exceptions.ResourceDuplicatedCode)
+	}
+}
+
+func TestRouter_Add_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"missing method", "/users"},
+		{"wildcard not last", GenerateEndpointKey(http.MethodGet, "/files/{path...}/meta")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newRouter().Add(tt.key, func(s Scope) {})
+
+			ex, ok := err.(*exceptions.Exception)
+			if !ok || ex.Code != exceptions.ResourceInvalidCode {
+				t.Errorf("Add(), got %v but want %v", err, exceptions.ResourceInvalidCode)
+			}
+		})
+	}
+}
+
+func TestRouter_Allowed(t *testing.T) {
+	//given
+	r := newRouter()
+	r.Add(GenerateEndpointKey(http.MethodGet, "/users/me"), func(s Scope) {})
+	r.Add(GenerateEndpointKey(http.MethodDelete, "/users/{id}"), func(s Scope) {})
+	r.Add(GenerateEndpointKey(http.MethodPatch, "/users/{id}"), func(s Scope) {})
+	r.Add(GenerateEndpointKey(http.MethodPost, "/users"), func(s Scope) {})
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"static and param", "/users/me", []string{"DELETE", "GET", "PATCH"}},
+		{"param only", "/users/123", []string{"DELETE", "PATCH"}},
+		{"static only", "/users", []string{"POST"}},
+		{"unknown path", "/orders", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Allowed(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,9 +12,7 @@ func GeneratePrivateKey(keySize int) (*rsa.PrivateKey, error) {
 		e := exceptions.NewBuilder()
 		e.SetCode(exceptions.ResourceNotGeneratedCode)
 		e.SetMessage(exceptions.ResourceNotGeneratedMessage)
-		e.Include(exceptions.Data{
-			Value: err.Error(),
-		})
+		e.SetCause(err)
 
 		return nil, e.Build()
 	}
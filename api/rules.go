@@ -0,0 +1,295 @@
+package api
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+//rule validates a value against the parameter
+//declared in the "validate" tag (eg. 3 for min=3).
+//Returns true if the value failed validation
+type rule func(v reflect.Value, param string) bool
+
+//FieldContext holds the field being validated along
+//with the struct it belongs to and the request scope
+type FieldContext struct {
+	Scope  Scope
+	Name   string
+	Value  reflect.Value
+	Param  string
+	Parent reflect.Value
+}
+
+//FieldRule is a rule with access to the whole field
+//context, used for cross-field rules or rules which
+//depend on the request. Returns true if the value
+//failed validation
+type FieldRule func(f FieldContext) bool
+
+//builtinRules are the rules understood by the
+//"validate" tag out of the box. The required
+//rule is handled apart since it depends on
+//whether the value was sent or not
+var builtinRules = map[string]rule{
+	"min":      minRule,
+	"max":      maxRule,
+	"len":      lenRule,
+	"gt":       gtRule,
+	"gte":      gteRule,
+	"lt":       ltRule,
+	"lte":      lteRule,
+	"oneof":    oneOfRule,
+	"regex":    regexRule,
+	"email":    emailRule,
+	"uuid":     uuidRule,
+	"url":      urlRule,
+	"datetime": datetimeRule,
+}
+
+//defaultRules retrieves a registry
+//holding the built-in rules
+func defaultRules() map[string]FieldRule {
+	registry := make(map[string]FieldRule, len(builtinRules)+2)
+
+	for name, r := range builtinRules {
+		r := r
+		registry[name] = func(f FieldContext) bool {
+			return r(f.Value, f.Param)
+		}
+	}
+	registry["eqfield"] = eqFieldRule
+	registry["nefield"] = neFieldRule
+
+	return registry
+}
+
+//RegisterRule includes a named rule for the "validate"
+//tag, replacing any rule with the same name
+func (e *engine) RegisterRule(name string, r ValidationRule) {
+	e.RegisterFieldRule(name, func(f FieldContext) bool {
+		return r(interfaceOf(f.Value))
+	})
+}
+
+//RegisterFieldRule includes a named rule for the "validate"
+//tag with access to the field context, replacing any
+//rule with the same name
+func (e *engine) RegisterFieldRule(name string, r FieldRule) {
+	if e.rules == nil {
+		e.rules = defaultRules()
+	}
+
+	e.rules[name] = r
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+//patterns caches the expressions
+//compiled by the regex rule
+var patterns sync.Map
+
+//measure retrieves the value of numbers and
+//the length of strings, slices and maps
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+//compare measures the value and compares it to the
+//parameter. Values that cannot be measured fail
+func compare(v reflect.Value, param string, failed func(value, limit float64) bool) bool {
+	value, ok := measure(v)
+	if !ok {
+		return true
+	}
+
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return true
+	}
+
+	return failed(value, limit)
+}
+
+//minRule ensures numbers are not lower and lengths
+//are not shorter than the parameter (eg. min=3)
+func minRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value < limit })
+}
+
+//maxRule ensures numbers are not greater and lengths
+//are not longer than the parameter (eg. max=10)
+func maxRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value > limit })
+}
+
+//lenRule ensures numbers or lengths are
+//equal to the parameter (eg. len=8)
+func lenRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value != limit })
+}
+
+//gtRule ensures numbers are greater and lengths
+//are longer than the parameter
+func gtRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value <= limit })
+}
+
+//gteRule ensures numbers or lengths are greater
+//than or equal to the parameter
+func gteRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value < limit })
+}
+
+//ltRule ensures numbers are lower and lengths
+//are shorter than the parameter
+func ltRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value >= limit })
+}
+
+//lteRule ensures numbers or lengths are lower
+//than or equal to the parameter
+func lteRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(value, limit float64) bool { return value > limit })
+}
+
+//oneOfRule ensures the value is one of the space
+//separated options (eg. oneof=asc desc)
+func oneOfRule(v reflect.Value, param string) bool {
+	if isEmptyString(v) {
+		return false
+	}
+
+	value := fmt.Sprint(v.Interface())
+	for _, option := range strings.Fields(param) {
+		if value == option {
+			return false
+		}
+	}
+	return true
+}
+
+//regexRule ensures strings match the expression in
+//the parameter. Expressions cannot contain commas
+func regexRule(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return true
+	}
+	if v.String() == "" {
+		return false
+	}
+
+	pattern, ok := patterns.Load(param)
+	if !ok {
+		compiled, err := regexp.Compile(param)
+		if err != nil {
+			return true
+		}
+		pattern, _ = patterns.LoadOrStore(param, compiled)
+	}
+
+	return !pattern.(*regexp.Regexp).MatchString(v.String())
+}
+
+//emailRule ensures strings are a bare email address
+func emailRule(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return true
+	}
+	if v.String() == "" {
+		return false
+	}
+
+	address, err := mail.ParseAddress(v.String())
+	return err != nil || address.Address != v.String()
+}
+
+//uuidRule ensures strings are a UUID
+//in its canonical textual form
+func uuidRule(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return true
+	}
+	if v.String() == "" {
+		return false
+	}
+
+	return !uuidPattern.MatchString(v.String())
+}
+
+//urlRule ensures strings are an absolute url
+func urlRule(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return true
+	}
+	if v.String() == "" {
+		return false
+	}
+
+	u, err := url.ParseRequestURI(v.String())
+	return err != nil || u.Scheme == "" || u.Host == ""
+}
+
+//datetimeRule ensures strings are a time in the layout
+//of the parameter (eg. datetime=2006-01-02)
+func datetimeRule(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return true
+	}
+	if v.String() == "" {
+		return false
+	}
+
+	_, err := time.Parse(param, v.String())
+	return err != nil
+}
+
+//eqFieldRule ensures the value equals the field of
+//the same struct named by the parameter
+//(eg. eqfield=Password)
+func eqFieldRule(f FieldContext) bool {
+	other, ok := siblingOf(f)
+	return !ok || !reflect.DeepEqual(interfaceOf(indirect(f.Value)), interfaceOf(other))
+}
+
+//neFieldRule ensures the value differs from the
+//field of the same struct named by the parameter
+func neFieldRule(f FieldContext) bool {
+	other, ok := siblingOf(f)
+	return !ok || reflect.DeepEqual(interfaceOf(indirect(f.Value)), interfaceOf(other))
+}
+
+//siblingOf retrieves the field of the same struct
+//named by the rule parameter, dereferenced so it
+//compares with the value of pointer fields
+func siblingOf(f FieldContext) (reflect.Value, bool) {
+	if f.Parent.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	other := f.Parent.FieldByName(f.Param)
+	return indirect(other), other.IsValid()
+}
+
+func isEmptyString(v reflect.Value) bool {
+	return v.Kind() == reflect.String && v.String() == ""
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"fmt"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		param string
+		v     any
+		want  bool
+	}{
+		{"min string succeeds", "min", "3", "abc", false},
+		{"min string fails", "min", "3", "ab", true},
+		{"min counts runes", "min", "3", "día", false},
+		{"min int succeeds", "min", "3", 3, false},
+		{"min int fails", "min", "3", 2, true},
+		{"min slice fails", "min", "1", []string{}, true},
+		{"min invalid param fails", "min", "a", 3, true},
+		{"min unsupported type fails", "min", "1", struct{}{}, true},
+		{"max string succeeds", "max", "3", "abc", false},
+		{"max string fails", "max", "3", "abcd", true},
+		{"max float fails", "max", "1.5", 1.6, true},
+		{"max uint succeeds", "max", "10", uint8(10), false},
+		{"len string succeeds", "len", "2", "ab", false},
+		{"len string fails", "len", "2", "abc", true},
+		{"len map succeeds", "len", "1", map[string]int{"a": 1}, false},
+		{"gt succeeds", "gt", "0", 1, false},
+		{"gt fails", "gt", "0", 0, true},
+		{"gte succeeds", "gte", "0", 0, false},
+		{"lt succeeds", "lt", "10", 9.9, false},
+		{"lt fails", "lt", "10", 10, true},
+		{"lte fails", "lte", "10", int64(11), true},
+		{"oneof succeeds", "oneof", "asc desc", "desc", false},
+		{"oneof fails", "oneof", "asc desc", "up", true},
+		{"oneof int succeeds", "oneof", "10 20", 20, false},
+		{"regex succeeds", "regex", "^[a-z]+$", "abc", false},
+		{"regex fails", "regex", "^[a-z]+$", "abc1", true},
+		{"regex invalid expression fails", "regex", "[", "abc", true},
+		{"email succeeds", "email", "", "art@company.com", false},
+		{"email fails", "email", "", "art@", true},
+		{"email with name fails", "email", "", "Art <art@company.com>", true},
+		{"uuid succeeds", "uuid", "", "123e4567-e89b-12d3-a456-426614174000", false},
+		{"uuid fails", "uuid", "", "123e4567e89b12d3a456426614174000", true},
+		{"url succeeds", "url", "", "https://company.com/a?b=c", false},
+		{"url fails", "url", "", "/a/b", true},
+		{"datetime succeeds", "datetime", "2006-01-02", "2022-12-31", false},
+		{"datetime fails", "datetime", "2006-01-02", "31/12/2022", true},
+		{"format rule on int fails", "email", "", 1, true},
+		{"format rule skips empty string", "email", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := builtinRules[tt.rule](reflect.ValueOf(tt.v), tt.param); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldRules(t *testing.T) {
+	type Signup struct {
+		Password string
+		Confirm  string
+		Previous string
+	}
+	parent := reflect.ValueOf(Signup{Password: "secret", Confirm: "secret", Previous: "old"})
+	tests := []struct {
+		name  string
+		rule  string
+		param string
+		field string
+		want  bool
+	}{
+		{"eqfield succeeds", "eqfield", "Password", "Confirm", false},
+		{"eqfield fails", "eqfield", "Previous", "Confirm", true},
+		{"eqfield unknown field fails", "eqfield", "Unknown", "Confirm", true},
+		{"nefield succeeds", "nefield", "Previous", "Password", false},
+		{"nefield fails", "nefield", "Confirm", "Password", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := FieldContext{
+				Name:   tt.field,
+				Value:  parent.FieldByName(tt.field),
+				Param:  tt.param,
+				Parent: parent,
+			}
+
+			if got := defaultRules()[tt.rule](f); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScope_JsonBody_pointerFieldRules(t *testing.T) {
+	//given
+	type Signup struct {
+		Password *string `json:"password"`
+		Confirm  *string `json:"confirm" validate:"eqfield=Password"`
+		Previous *string `json:"previous" validate:"nefield=Password"`
+	}
+	body := `{"password":"a","confirm":"a","previous":"a"}`
+	req := httptest.NewRequest(http.MethodPost, "/some-url", strings.NewReader(body))
+	scope := scope{r: req}
+	expected := []exceptions.Data{
+		{Name: "previous", Tag: "nefield=Password", Value: "a"},
+	}
+
+	//when
+	err := scope.ValidateJsonBody(&Signup{})
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateJsonBody(), exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateJsonBody(), got %v but want %v", ex.Data, expected)
+	}
+}
+
+func TestScope_unknownRule(t *testing.T) {
+	type Page struct {
+		Limit int `json:"limit" query:"limit" header:"limit" validate:"required,mni=3"`
+	}
+	tests := []struct {
+		name     string
+		validate func(s *scope, payload *Page) error
+	}{
+		{"query", func(s *scope, payload *Page) error { return s.ValidateQuery(payload) }},
+		{"headers", func(s *scope, payload *Page) error { return s.ValidateHeaders(payload) }},
+		{"json body", func(s *scope, payload *Page) error { return s.ValidateJsonBody(payload) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			req, _ := http.NewRequest(http.MethodGet, "/some-url?limit=5", strings.NewReader(`{"limit":5}`))
+			req.Header.Set("limit", "5")
+			scope := scope{r: req}
+
+			//when
+			err := tt.validate(&scope, &Page{})
+
+			//then
+			ex, ok := err.(*exceptions.Exception)
+			if !ok || ex.Code != exceptions.InternalErrorCode {
+				t.Fatalf("Validate(), got %v but want %v", err, exceptions.InternalErrorCode)
+			}
+
+			if !strings.Contains(fmt.Sprint(ex.Unwrap()), `"mni"`) {
+				t.Errorf("Validate(), got %v but want the mni rule as cause", ex.Unwrap())
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_unknownRule(t *testing.T) {
+	type Page struct {
+		Limit int `json:"limit" validate:"mni=3"`
+	}
+	tests := []struct {
+		name string
+		bind func(s Scope, payload *Page) error
+	}{
+		{"Bind", func(s Scope, payload *Page) error { return s.Bind(payload) }},
+		{"BindRequest", func(s Scope, payload *Page) error { return s.BindRequest(payload) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes: newRouter(),
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodPost, "/pages"), func(s Scope) {
+				var page Page
+				if err := tt.bind(s, &page); err != nil {
+					return
+				}
+				s.Reply(http.StatusOK, page)
+			})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/pages", strings.NewReader(`{"limit":5}`))
+			r.Header.Set(RequestIDHeader, "req-1")
+			expected := `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusInternalServerError)
+			}
+
+			if w.Body.String() != expected {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), expected)
+			}
+		})
+	}
+}
+
+func TestEngine_RegisterRule(t *testing.T) {
+	//given
+	type Order struct {
+		Tenant string `json:"tenant" header:"tenant" query:"tenant" validate:"tenant"`
+		Code   string `json:"code" header:"code" query:"code" validate:"even"`
+	}
+	e := engine{
+		routes: newRouter(),
+	}
+	e.RegisterRule("even", func(cv any) bool {
+		return len(cv.(string))%2 != 0
+	})
+	e.RegisterFieldRule("tenant", func(f FieldContext) bool {
+		data, err := f.Scope.GetData("tenants")
+		if err != nil {
+			return true
+		}
+		return !data.(map[string]bool)[f.Value.String()]
+	})
+	e.AddInterceptor(&tenants{})
+	errs := make(map[string]error)
+	e.routes.Add(GenerateEndpointKey(http.MethodPost, "/orders"), func(s Scope) {
+		errs["query"] = s.ValidateQuery(&Order{})
+		errs["header"] = s.ValidateHeaders(&Order{})
+		errs["body"] = s.Bind(&Order{})
+	})
+	w := httptest.NewRecorder()
+	body := `{"tenant":"unknown","code":"abc"}`
+	r, _ := http.NewRequest(http.MethodPost, "/orders?tenant=unknown&code=abc", strings.NewReader(body))
+	r.Header.Set("tenant", "unknown")
+	r.Header.Set("code", "abc")
+	expected := map[string][]exceptions.Data{
+		"query": {
+			{Name: "Tenant", Tag: "tenant", Value: "unknown"},
+			{Name: "Code", Tag: "even", Value: "abc"},
+		},
+		"header": {
+			{Name: "Tenant", Tag: "tenant", Value: "unknown"},
+			{Name: "Code", Tag: "even", Value: "abc"},
+		},
+		"body": {
+			{Name: "tenant", Tag: "tenant", Value: "unknown"},
+			{Name: "code", Tag: "even", Value: "abc"},
+		},
+	}
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	for source, err := range errs {
+		ex, ok := err.(*exceptions.Exception)
+		if !ok {
+			t.Fatalf("%s, exception expected but got %v", source, err)
+		}
+
+		if !reflect.DeepEqual(ex.Data, expected[source]) {
+			t.Errorf("%s, got %v but want %v", source, ex.Data, expected[source])
+		}
+	}
+}
+
+//tenants is an interceptor which loads
+//the known tenants into the scope
+type tenants struct{}
+
+func (i *tenants) Before(s Scope) error {
+	return s.SetData("tenants", map[string]bool{"acme": true})
+}
+
+func (i *tenants) After(s Scope) error {
+	return nil
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"log"
 	"net/http"
+	"strings"
 )
 
 type Scope interface {
@@ -13,10 +17,19 @@ type Scope interface {
 	Method() string
 	Path() string
 	Reply(status int, body interface{})
+	Fail(err error)
+	Status() int
 	QueryValue(key string) string
+	PathValue(name string) string
+	Peer() *Peer
+	RequestID() string
+	Context() context.Context
+	Span() *Span
 	ValidateQuery(payload interface{}) error
 	ValidateJsonBody(payload interface{}) error
 	ValidateHeaders(payload interface{}) error
+	Bind(payload interface{}) error
+	BindRequest(payload interface{}) error
 }
 
 // scope holds Api Handler context
@@ -26,6 +39,53 @@ type scope struct {
 	s int
 	b []byte
 	d map[string]any
+	p map[string]string
+
+	//enc encodes the replies in the
+	//media type accepted by the client
+	enc Encoder
+
+	//fallbacks are the other encoders accepted
+	//by the client, by preference, used if a
+	//reply cannot be encoded by enc
+	fallbacks []Encoder
+
+	//decoders decode request bodies
+	//by their Content-Type
+	decoders []Decoder
+
+	//rules are the validation rules
+	//registered in the engine
+	rules map[string]FieldRule
+
+	//statuses are the statuses replied
+	//for the exception codes
+	statuses map[exceptions.Code]int
+
+	//problems renders exceptions
+	//as problem details
+	problems Problems
+
+	//replyType is the media type of the reply
+	//if it is not the negotiated one (eg.
+	//problem details)
+	replyType string
+
+	//id correlates the request
+	//across services
+	id string
+
+	//route is the url template
+	//matched by the request
+	route string
+
+	//ctx bounds the handling
+	//of the request
+	ctx context.Context
+
+	//code is the code of the
+	//exception replied, if any
+	code exceptions.Code
 }
 
 //GetData gets available additional
@@ -79,16 +139,43 @@ func (s *scope) Path() string {
 	return s.r.URL.RequestURI()
 }
 
-// Reply replies to client with json format
+// Reply replies to client in the media
+// type negotiated through the Accept header,
+// or in the next one accepted by the client
+// if the body cannot be encoded in it
 func (s *scope) Reply(status int, body interface{}) {
-	bodyByte, err := json.Marshal(body)
+	s.replyType = ""
+	s.code = ""
+	if ex, ok := body.(*exceptions.Exception); ok {
+		s.code = ex.Code
+		ex = s.correlate(ex)
+		body = ex
+		if mediaType, ok := s.problems.mediaType(s.encoder().MediaType()); ok && s.problems.Enabled {
+			body = s.problems.problem(ex, status, s.Path())
+			s.replyType = mediaType
+		}
+	}
+
+	bodyByte, err := s.encoder().Encode(body)
+	if err != nil && s.replyType == "" {
+		for _, enc := range s.fallbacks {
+			if b, encErr := enc.Encode(body); encErr == nil {
+				s.enc = enc
+				bodyByte, err = b, nil
+				break
+			}
+		}
+	}
+
 	if err != nil {
 		e := exceptions.NewBuilder()
 		e.SetCode(exceptions.ResourceNotEncodedCode)
 		e.SetMessage(exceptions.ResourceNotEncodedMessage)
-		e.Include(exceptions.Data{Value: err})
+		e.SetCause(err)
+		s.logf("failed: %v", e.Build())
 
-		bodyByte, _ = json.Marshal(e.Build())
+		bodyByte, _ = s.encoder().Encode(s.correlate(e.Build()))
+		s.code = exceptions.ResourceNotEncodedCode
 		status = http.StatusInternalServerError
 	}
 
@@ -96,12 +183,111 @@ func (s *scope) Reply(status int, body interface{}) {
 	s.b = bodyByte
 }
 
+//replyRaw replies with a body already
+//encoded in the given media type
+func (s *scope) replyRaw(status int, mediaType string, body []byte) {
+	s.s = status
+	s.b = body
+	s.replyType = mediaType
+	s.code = ""
+}
+
+//correlate retrieves a copy of the exception
+//holding the identifier of the request
+func (s *scope) correlate(ex *exceptions.Exception) *exceptions.Exception {
+	if s.id == "" {
+		return ex
+	}
+
+	correlated := *ex
+	correlated.RequestID = s.id
+	return &correlated
+}
+
+//logf logs a line about the request
+//prefixed by its identifier
+func (s *scope) logf(format string, v ...any) {
+	prefix := fmt.Sprintf("%s %s ", s.Method(), s.Path())
+	if s.id != "" {
+		prefix = fmt.Sprintf("[%s] %s", s.id, prefix)
+	}
+
+	log.Printf(prefix+format, v...)
+}
+
+//mediaType retrieves the media type of the reply,
+//which is the one of the negotiated encoder
+//unless replied with another one
+func (s *scope) mediaType() string {
+	if s.replyType != "" {
+		return s.replyType
+	}
+
+	return s.encoder().MediaType()
+}
+
+//encoder retrieves the negotiated
+//encoder, JSON by default
+func (s *scope) encoder() Encoder {
+	if s.enc == nil {
+		return NewJSONEncoder()
+	}
+
+	return s.enc
+}
+
+//Status retrieves the status code
+//the request will be replied with
+func (s *scope) Status() int {
+	return s.s
+}
+
+//replyError replies with an error returned by an
+//interceptor. Exceptions, even if wrapped, keep the
+//error status previously set through Reply, otherwise
+//the error is replied through Fail
+func (s *scope) replyError(err error) {
+	var ex *exceptions.Exception
+	if errors.As(err, &ex) && ex != nil && s.s >= http.StatusBadRequest {
+		s.Reply(s.s, ex)
+		return
+	}
+
+	s.Fail(err)
+}
+
 // QueryValue extracts a string from Query parameter
 // Sets default value if absent (eg. /a?b=c)
 func (s *scope) QueryValue(key string) string {
 	return s.r.URL.Query().Get(key)
 }
 
+//PathValue extracts a parameter declared by the url
+//template of the matched route (eg. "/users/{id}")
+//Returns an empty string if absent
+func (s *scope) PathValue(name string) string {
+	return s.p[strings.ToLower(name)]
+}
+
+//Peer retrieves the identity of the client
+//verified through mutual TLS. Returns nil
+//if the client was not authenticated
+func (s *scope) Peer() *Peer {
+	return newPeer(s.r)
+}
+
+//Context retrieves the context of the request. It is
+//canceled when the client disconnects or the handler
+//times out, so it should be passed to anything the
+//handler starts
+func (s *scope) Context() context.Context {
+	if s.ctx == nil {
+		return s.r.Context()
+	}
+
+	return s.ctx
+}
+
 //NewScope creates a Handler's scope instance
 func NewScope(w http.ResponseWriter, r *http.Request) *scope {
 	return &scope{
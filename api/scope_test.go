@@ -284,6 +284,26 @@ func TestScope_QueryValue(t *testing.T) {
 	}
 }
 
+func TestScope_PathValue(t *testing.T) {
+	//given
+	expected := "123"
+	scope := scope{
+		p: map[string]string{"userid": expected},
+	}
+
+	//when
+	actual := scope.PathValue("userId")
+
+	//then
+	if actual != expected {
+		t.Errorf(
+			"PathValue(), got %v but want %v",
+			actual,
+			expected,
+		)
+	}
+}
+
 func TestNewRequest(t *testing.T) {
 	//given
 	w := httptest.NewRecorder()
@@ -71,6 +71,7 @@ func NewTestScope(method string, req Request, c Controller) *scopeTest {
 			r: r,
 			w: w,
 			d: make(map[string]any),
+			p: req.PathValues(),
 		},
 		c: c,
 	}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"runtime/debug"
+)
+
+//defaultStatuses are the statuses replied
+//for the built-in exception codes
+func defaultStatuses() map[exceptions.Code]int {
+	return map[exceptions.Code]int{
+		exceptions.ResourceNotFoundCode:    http.StatusNotFound,
+		exceptions.ResourceDuplicatedCode:  http.StatusConflict,
+		exceptions.ResourceInvalidCode:     http.StatusBadRequest,
+		exceptions.ResourceUnsupportedCode: http.StatusUnsupportedMediaType,
+		exceptions.InternalErrorCode:       http.StatusInternalServerError,
+		exceptions.ResourceTimedOutCode:    http.StatusGatewayTimeout,
+		exceptions.ResourceCanceledCode:    http.StatusServiceUnavailable,
+		exceptions.ResourceTooLargeCode:    http.StatusRequestEntityTooLarge,
+	}
+}
+
+//RegisterStatus sets the status replied through
+//Scope.Fail for exceptions with the given code
+func (e *engine) RegisterStatus(code exceptions.Code, status int) {
+	if e.statuses == nil {
+		e.statuses = defaultStatuses()
+	}
+
+	e.statuses[code] = status
+}
+
+//Fail replies with the given error. Exceptions, even if
+//wrapped by other errors, are replied with the status
+//registered for their code and default to
+//http.StatusInternalServerError. Their cause, stack and
+//wrapping errors are only logged. Any other error is
+//logged and replied as an internal error so it is not
+//exposed to the client
+func (s *scope) Fail(err error) {
+	var ex *exceptions.Exception
+	if !errors.As(err, &ex) || ex == nil {
+		s.logf("failed: %v", err)
+		s.Reply(http.StatusInternalServerError, internalError())
+		return
+	}
+
+	if err != ex || ex.Unwrap() != nil || ex.Stack() != "" {
+		s.logf("failed: %v\n%s", err, ex.Stack())
+	}
+
+	s.Reply(s.statusOf(ex.Code), ex)
+}
+
+//statusOf retrieves the status registered
+//for the given exception code
+func (s *scope) statusOf(code exceptions.Code) int {
+	statuses := s.statuses
+	if statuses == nil {
+		statuses = defaultStatuses()
+	}
+
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+//recovered replies with an internal error once the
+//handler panicked with v. The panic and its stack
+//are logged but never exposed to the client
+func (s *scope) recovered(v any) {
+	if v != http.ErrAbortHandler {
+		s.logf("panicked: %v\n%s", v, debug.Stack())
+	}
+
+	s.Reply(http.StatusInternalServerError, internalError())
+}
+
+//internalError builds the exception replied
+//for errors which must not be exposed
+func internalError() *exceptions.Exception {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.InternalErrorCode)
+	ex.SetMessage(exceptions.InternalErrorMessage)
+
+	return ex.Build()
+}
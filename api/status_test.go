@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScope_Fail(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		response string
+	}{
+		{"not found", exceptionOf(exceptions.ResourceNotFoundCode), http.StatusNotFound, `{"code":"fwork_rnf","message":"some message","request_id":"req-1"}`},
+		{"duplicated", exceptionOf(exceptions.ResourceDuplicatedCode), http.StatusConflict, `{"code":"fwork_rd","message":"some message","request_id":"req-1"}`},
+		{"invalid", exceptionOf(exceptions.ResourceInvalidCode), http.StatusBadRequest, `{"code":"fwork_ri","message":"some message","request_id":"req-1"}`},
+		{"registered", exceptionOf("app_pr"), http.StatusPaymentRequired, `{"code":"app_pr","message":"some message","request_id":"req-1"}`},
+		{"unregistered", exceptionOf("app_unknown"), http.StatusInternalServerError, `{"code":"app_unknown","message":"some message","request_id":"req-1"}`},
+		{"caused", causedBy(errors.New("password=secret")), http.StatusNotFound, `{"code":"fwork_rnf","message":"resource not found","request_id":"req-1"}`},
+		{"unknown error", errors.New("password=secret"), http.StatusInternalServerError, `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`},
+		{"wrapped", fmt.Errorf("load user: %w", exceptionOf(exceptions.ResourceNotFoundCode)), http.StatusNotFound, `{"code":"fwork_rnf","message":"some message","request_id":"req-1"}`},
+		{"wrapped twice", fmt.Errorf("handler: %w", fmt.Errorf("load user: %w", causedBy(errors.New("password=secret")))), http.StatusNotFound, `{"code":"fwork_rnf","message":"resource not found","request_id":"req-1"}`},
+		{"wrapped registered", fmt.Errorf("charge: %w", exceptionOf("app_pr")), http.StatusPaymentRequired, `{"code":"app_pr","message":"some message","request_id":"req-1"}`},
+		{"nil error", nil, http.StatusInternalServerError, `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`},
+		{"nil exception", (*exceptions.Exception)(nil), http.StatusInternalServerError, `{"code":"fwork_ie","message":"internal error","request_id":"req-1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			url := "/some-url"
+			e := engine{
+				routes: newRouter(),
+			}
+			e.RegisterStatus("app_pr", http.StatusPaymentRequired)
+			key := GenerateEndpointKey(http.MethodGet, url)
+			e.routes.Add(key, func(s Scope) {
+				s.Fail(tt.err)
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, url, nil)
+			r.Header.Set(RequestIDHeader, "req-1")
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf("Fail(), got %v but want %v", w.Code, tt.status)
+			}
+
+			if w.Body.String() != tt.response {
+				t.Errorf("Fail(), got %v but want %v", w.Body.String(), tt.response)
+			}
+		})
+	}
+}
+
+func exceptionOf(code exceptions.Code) *exceptions.Exception {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(code)
+	ex.SetMessage("some message")
+
+	return ex.Build()
+}
+
+func causedBy(err error) *exceptions.Exception {
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceNotFoundCode)
+	ex.SetMessage(exceptions.ResourceNotFoundMessage)
+	ex.SetCause(err)
+	ex.CaptureStack()
+
+	return ex.Build()
+}
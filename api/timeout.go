@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"time"
+)
+
+//Timeouts bounds the time given to handlers. Once
+//exceeded, the context of the scope is canceled and
+//the request is replied with 504 right away, while
+//whatever the handler replies afterwards is discarded
+type Timeouts struct {
+	//Handler bounds every handler.
+	//Handlers are unbounded if zero
+	Handler time.Duration
+
+	//Routes overrides Handler for the routes with
+	//the given key (eg. GenerateEndpointKey(
+	//http.MethodGet, "/users/{id}"))
+	Routes map[string]time.Duration
+}
+
+//of retrieves the timeout of the route
+//matching the method and url template
+func (t Timeouts) of(method, template string) time.Duration {
+	if d, ok := t.Routes[GenerateEndpointKey(method, template)]; ok {
+		return d
+	}
+
+	if method == http.MethodHead {
+		return t.of(http.MethodGet, template)
+	}
+
+	return t.Handler
+}
+
+//bound wraps the handler so its scope is bounded by the
+//context of the request and the timeout of the route.
+//Handlers with a timeout are detached, so the request is
+//replied as soon as its context is done. Requests whose
+//context is done are replied with 504 if they timed out
+//and 503 if they were canceled, whatever the handler
+//replied. Handlers must watch Scope.Context() to stop
+//working on requests which were already replied
+func (e *engine) bound(s *scope, handler Handler) Handler {
+	return func(sc Scope) {
+		ctx := s.Context()
+		if timeout := e.config.Timeouts.of(s.Method(), s.route); timeout > 0 && s.route != "" {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			s.ctx = ctx
+			detach(s, handler)
+		} else {
+			s.ctx = ctx
+			handler(sc)
+		}
+
+		if err := ctx.Err(); err != nil {
+			s.Fail(contextException(err))
+		}
+	}
+}
+
+//detach runs the handler in its own goroutine on a copy
+//of the scope until it returns or the context of the
+//scope is done. The reply and data of the copy are only
+//kept if the handler returned in time, otherwise they
+//are discarded as in http.TimeoutHandler. Panics of the
+//handler are raised again in the calling goroutine
+func detach(s *scope, handler Handler) {
+	detached := *s
+	detached.d = make(map[string]any, len(s.d))
+	for k, v := range s.d {
+		detached.d[k] = v
+	}
+
+	done := make(chan struct{})
+	panics := make(chan any, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				panics <- v
+				return
+			}
+			close(done)
+		}()
+
+		handler(&detached)
+	}()
+
+	select {
+	case <-done:
+		*s = detached
+	case v := <-panics:
+		panic(v)
+	case <-s.ctx.Done():
+	}
+}
+
+//contextException builds the exception replied
+//for a request whose context is done
+func contextException(err error) error {
+	ex := exceptions.NewBuilder()
+	ex.SetCause(err)
+
+	if err == context.DeadlineExceeded {
+		ex.SetCode(exceptions.ResourceTimedOutCode)
+		ex.SetMessage(exceptions.ResourceTimedOutMessage)
+	} else {
+		ex.SetCode(exceptions.ResourceCanceledCode)
+		ex.SetMessage(exceptions.ResourceCanceledMessage)
+	}
+
+	return ex.Build()
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"github.com/ravelo-systematic-solutions/fwork/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type contextKey string
+
+func TestScope_Context(t *testing.T) {
+	//given
+	ctx := context.WithValue(context.Background(), contextKey("key"), "value")
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/some-url", nil)
+	s := NewScope(httptest.NewRecorder(), r)
+
+	//when
+	actual := s.Context()
+
+	//then
+	if actual.Value(contextKey("key")) != "value" {
+		t.Errorf("Context(), got %v but want %v", actual.Value(contextKey("key")), "value")
+	}
+}
+
+func TestEngine_ServeHTTP_timeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts Timeouts
+		status   int
+		code     exceptions.Code
+	}{
+		{"unbounded", Timeouts{}, http.StatusOK, ""},
+		{"global", Timeouts{Handler: time.Millisecond}, http.StatusGatewayTimeout, exceptions.ResourceTimedOutCode},
+		{
+			"route shorter than global",
+			Timeouts{Handler: time.Hour, Routes: map[string]time.Duration{GenerateEndpointKey(http.MethodGet, "/users/{id}"): time.Millisecond}},
+			http.StatusGatewayTimeout,
+			exceptions.ResourceTimedOutCode,
+		},
+		{
+			"route of another case",
+			Timeouts{Routes: map[string]time.Duration{GenerateEndpointKey(http.MethodGet, "/Users/{ID}"): time.Millisecond}},
+			http.StatusGatewayTimeout,
+			exceptions.ResourceTimedOutCode,
+		},
+		{
+			"route longer than global",
+			Timeouts{Handler: time.Millisecond, Routes: map[string]time.Duration{GenerateEndpointKey(http.MethodGet, "/users/{id}"): time.Hour}},
+			http.StatusOK,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := engine{
+				routes: newRouter(),
+				config: Config{Timeouts: tt.timeouts},
+			}
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {
+				select {
+				case <-s.Context().Done():
+				case <-time.After(20 * time.Millisecond):
+				}
+				s.Reply(http.StatusOK, response.Void{})
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			if w.Code != tt.status {
+				t.Errorf("ServeHTTP(), got %v but want %v", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestEngine_ServeHTTP_canceled(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+	}
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/some-url"), func(s Scope) {
+		<-s.Context().Done()
+		s.Reply(http.StatusOK, response.Void{})
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/some-url", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	expected := `{"code":"fwork_rca","message":"resource canceled","request_id":"req-1"}`
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusServiceUnavailable)
+	}
+
+	if w.Body.String() != expected {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Body.String(), expected)
+	}
+}
+
+func TestEngine_ServeHTTP_uncooperative(t *testing.T) {
+	//given
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	e := engine{
+		routes: newRouter(),
+		config: Config{Timeouts: Timeouts{Handler: time.Millisecond}},
+	}
+	var late any
+	e.AddInterceptor(&afterFunc{func(s Scope) {
+		late, _ = s.GetData("late")
+	}})
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/some-url"), func(s Scope) {
+		defer close(finished)
+		<-release
+		s.OverrideData("late", true)
+		s.Reply(http.StatusOK, response.Void{})
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+	close(release)
+	<-finished
+
+	//then
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusGatewayTimeout)
+	}
+
+	if late != nil {
+		t.Errorf("GetData(), got %v but want the data set after the timeout discarded", late)
+	}
+}
+
+func TestEngine_ServeHTTP_detachedData(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+		config: Config{Timeouts: Timeouts{Handler: time.Hour}},
+	}
+	var actual any
+	e.AddInterceptor(&afterFunc{func(s Scope) {
+		actual, _ = s.GetData("user")
+	}})
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/some-url"), func(s Scope) {
+		s.OverrideData("user", "jane")
+		s.Reply(http.StatusAccepted, response.Void{})
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusAccepted {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusAccepted)
+	}
+
+	if actual != "jane" {
+		t.Errorf("GetData(), got %v but want %v", actual, "jane")
+	}
+}
+
+func TestEngine_ServeHTTP_detachedPanic(t *testing.T) {
+	//given
+	e := engine{
+		routes: newRouter(),
+		config: Config{Timeouts: Timeouts{Handler: time.Hour}},
+	}
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/some-url"), func(s Scope) {
+		var users map[string]int
+		users["jane"]++
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("ServeHTTP(), got %v but want %v", w.Code, http.StatusInternalServerError)
+	}
+}
+
+//afterFunc calls a function once the
+//handler of the request returned
+type afterFunc struct {
+	f func(s Scope)
+}
+
+func (a *afterFunc) Before(s Scope) error {
+	return nil
+}
+
+func (a *afterFunc) After(s Scope) error {
+	a.f(s)
+	return nil
+}
+
+func TestEngine_ServeHTTP_propagatesCancellation(t *testing.T) {
+	//given
+	started := make(chan context.Context, 1)
+	e := engine{
+		routes: newRouter(),
+		config: Config{Timeouts: Timeouts{Handler: time.Hour}},
+	}
+	e.routes.Add(GenerateEndpointKey(http.MethodGet, "/some-url"), func(s Scope) {
+		started <- s.Context()
+		s.Reply(http.StatusAccepted, response.Void{})
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+
+	//when
+	e.ServeHTTP(w, r)
+
+	//then
+	select {
+	case <-(<-started).Done():
+	case <-time.After(time.Second):
+		t.Errorf("Context(), should be done once the handler returns")
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+//DefaultReloadInterval is how often certificate
+//files are checked for changes when
+//TLS.ReloadInterval is not set
+const DefaultReloadInterval = 30 * time.Second
+
+//TLS references existing certificates used
+//instead of self-signing one. Certificate takes
+//precedence over CertFile and KeyFile
+type TLS struct {
+	//CertFile & KeyFile are PEM encoded files
+	//which are reloaded when changed on disk
+	CertFile string
+	KeyFile  string
+
+	//Certificate is served as is
+	Certificate *tls.Certificate
+
+	//ReloadInterval is how often
+	//the files are checked for changes
+	ReloadInterval time.Duration
+}
+
+//ClientAuthMode defines whether client
+//certificates are requested and verified
+type ClientAuthMode string
+
+const (
+	//ClientAuthNone does not request client certificates
+	ClientAuthNone ClientAuthMode = "none"
+	//ClientAuthRequest requests a client certificate
+	//and verifies it only when one is sent
+	ClientAuthRequest ClientAuthMode = "request"
+	//ClientAuthRequireAndVerify rejects connections
+	//without a valid client certificate
+	ClientAuthRequireAndVerify ClientAuthMode = "require-and-verify"
+)
+
+//ClientAuth configures mutual TLS. Client
+//certificates are verified against CAs, or
+//against the PEM bundle in CAFile if not set
+type ClientAuth struct {
+	Mode   ClientAuthMode
+	CAFile string
+	CAs    *x509.CertPool
+}
+
+//apply configures the client authentication of
+//tlsConfig. An exception will be thrown if the
+//mode is unknown or the CAs cannot be loaded
+func (c ClientAuth) apply(tlsConfig *tls.Config) error {
+	switch c.Mode {
+	case "", ClientAuthNone:
+		tlsConfig.ClientAuth = tls.NoClientCert
+		return nil
+	case ClientAuthRequest:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequireAndVerify:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceInvalidCode)
+		e.SetMessage(exceptions.ResourceInvalidMessage)
+		e.Include(exceptions.Data{Name: "Mode", Value: c.Mode})
+
+		return e.Build()
+	}
+
+	pool, err := c.pool()
+	if err != nil {
+		return err
+	}
+	tlsConfig.ClientCAs = pool
+
+	return nil
+}
+
+//pool retrieves the CAs used to
+//verify client certificates
+func (c ClientAuth) pool() (*x509.CertPool, error) {
+	if c.CAs != nil {
+		return c.CAs, nil
+	}
+
+	if c.CAFile == "" {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceInvalidCode)
+		e.SetMessage(exceptions.ResourceInvalidMessage)
+		e.Include(exceptions.Data{Name: "CAFile"})
+
+		return nil, e.Build()
+	}
+
+	caPEM, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotFoundCode)
+		e.SetMessage(exceptions.ResourceNotFoundMessage)
+		e.SetCause(err)
+
+		return nil, e.Build()
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceInvalidCode)
+		e.SetMessage(exceptions.ResourceInvalidMessage)
+		e.Include(exceptions.Data{Name: "CAFile", Value: c.CAFile})
+
+		return nil, e.Build()
+	}
+
+	return pool, nil
+}
+
+//certificateLoader serves a certificate loaded from
+//PEM files, reloading it when the files change
+type certificateLoader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+//GetCertificate retrieves the latest certificate. Files
+//are checked at most once per interval and the previous
+//certificate is kept if they cannot be loaded
+func (l *certificateLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.checked) < l.interval {
+		return l.cert, nil
+	}
+	l.checked = time.Now()
+
+	modTime, err := l.lastModified()
+	if err != nil || !modTime.After(l.modTime) {
+		return l.cert, nil
+	}
+
+	if err := l.load(modTime); err != nil {
+		log.Printf(
+			"Failed to reload certificate: %v",
+			err,
+		)
+	}
+
+	return l.cert, nil
+}
+
+//load reads the certificate from
+//disk and keeps it in memory
+func (l *certificateLoader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourcesNotPairedCode)
+		e.SetMessage(exceptions.ResourcesNotPairedMessage)
+		e.SetCause(err)
+
+		return e.Build()
+	}
+
+	l.cert = &cert
+	l.modTime = modTime
+
+	return nil
+}
+
+//lastModified retrieves the latest modification
+//time of the certificate and key files
+func (l *certificateLoader) lastModified() (time.Time, error) {
+	certInfo, err := os.Stat(l.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	keyInfo, err := os.Stat(l.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+
+	return certInfo.ModTime(), nil
+}
+
+//newCertificateLoader loads the certificate
+//files. An exception will be thrown if
+//they cannot be loaded
+func newCertificateLoader(conf TLS) (*certificateLoader, error) {
+	l := &certificateLoader{
+		certFile: conf.CertFile,
+		keyFile:  conf.KeyFile,
+		interval: conf.ReloadInterval,
+		checked:  time.Now(),
+	}
+
+	if l.interval <= 0 {
+		l.interval = DefaultReloadInterval
+	}
+
+	modTime, err := l.lastModified()
+	if err != nil {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceNotFoundCode)
+		e.SetMessage(exceptions.ResourceNotFoundMessage)
+		e.SetCause(err)
+
+		return nil, e.Build()
+	}
+
+	if err := l.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+//newTLSConfig creates the TLS configuration
+//serving the certificates referenced by conf
+func newTLSConfig(conf TLS) (*tls.Config, error) {
+	if conf.Certificate != nil {
+		return &tls.Config{
+			Certificates: []tls.Certificate{*conf.Certificate},
+		}, nil
+	}
+
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		e := exceptions.NewBuilder()
+		e.SetCode(exceptions.ResourceInvalidCode)
+		e.SetMessage(exceptions.ResourceInvalidMessage)
+		e.Include(exceptions.Data{Name: "TLS"})
+
+		return nil, e.Build()
+	}
+
+	loader, err := newCertificateLoader(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		GetCertificate: loader.GetCertificate,
+	}, nil
+}
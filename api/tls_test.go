@@ -0,0 +1,244 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//writeCertificate writes a self-signed PEM certificate
+//and key with the given serial number into dir
+func writeCertificate(t *testing.T, dir string, serial int64) (string, string) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().AddDate(0, 0, 1),
+	}
+	certBytes, _ := x509.CreateCertificate(rand.Reader, cert, cert, &privateKey.PublicKey, privateKey)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatalf("unable to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatalf("unable to write key: %v", err)
+	}
+
+	return certFile, keyFile
+}
+
+func serialOf(t *testing.T, cert *tls.Certificate) int64 {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("unable to parse certificate: %v", err)
+	}
+
+	return leaf.SerialNumber.Int64()
+}
+
+func TestNewEngineWithTLS_files(t *testing.T) {
+	//given
+	certFile, keyFile := writeCertificate(t, t.TempDir(), 1)
+	config := Config{
+		TLS: TLS{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+	}
+
+	//when
+	e, err := NewEngineWithTLS(config)
+
+	//then
+	if err != nil {
+		t.Fatalf("NewEngineWithTLS(), got unexpected error %v", err)
+	}
+
+	cert, _ := e.server.TLSConfig.GetCertificate(nil)
+	if serialOf(t, cert) != 1 {
+		t.Errorf("GetCertificate(), got %v but want %v", serialOf(t, cert), 1)
+	}
+}
+
+func TestNewEngineWithTLS_certificate(t *testing.T) {
+	//given
+	certFile, keyFile := writeCertificate(t, t.TempDir(), 2)
+	cert, _ := tls.LoadX509KeyPair(certFile, keyFile)
+	config := Config{
+		TLS: TLS{
+			Certificate: &cert,
+		},
+	}
+
+	//when
+	e, err := NewEngineWithTLS(config)
+
+	//then
+	if err != nil {
+		t.Fatalf("NewEngineWithTLS(), got unexpected error %v", err)
+	}
+
+	if len(e.server.TLSConfig.Certificates) != 1 {
+		t.Errorf(
+			"NewEngineWithTLS(), got %v certificates but want %v",
+			len(e.server.TLSConfig.Certificates),
+			1,
+		)
+	}
+}
+
+func TestNewEngineWithTLS_error(t *testing.T) {
+	tests := []struct {
+		name string
+		conf TLS
+		code exceptions.Code
+	}{
+		{"missing configuration", TLS{}, exceptions.ResourceInvalidCode},
+		{"missing files", TLS{CertFile: "/missing/cert.pem", KeyFile: "/missing/key.pem"}, exceptions.ResourceNotFoundCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEngineWithTLS(Config{TLS: tt.conf})
+
+			ex, ok := err.(*exceptions.Exception)
+			if !ok || ex.Code != tt.code {
+				t.Errorf("NewEngineWithTLS(), got %v but want %v", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestCertificateLoader_reload(t *testing.T) {
+	//given
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, 1)
+	loader, _ := newCertificateLoader(TLS{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Nanosecond,
+	})
+	writeCertificate(t, dir, 2)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+
+	//when
+	cert, err := loader.GetCertificate(nil)
+
+	//then
+	if err != nil {
+		t.Fatalf("GetCertificate(), got unexpected error %v", err)
+	}
+
+	if serialOf(t, cert) != 2 {
+		t.Errorf("GetCertificate(), got %v but want %v", serialOf(t, cert), 2)
+	}
+}
+
+func TestCertificateLoader_reloadError(t *testing.T) {
+	//given
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, 1)
+	loader, _ := newCertificateLoader(TLS{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Nanosecond,
+	})
+	os.WriteFile(certFile, []byte("invalid"), 0600)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+
+	//when
+	cert, _ := loader.GetCertificate(nil)
+
+	//then
+	if serialOf(t, cert) != 1 {
+		t.Errorf("GetCertificate(), got %v but want %v", serialOf(t, cert), 1)
+	}
+}
+
+func TestClientAuth_apply(t *testing.T) {
+	pool := x509.NewCertPool()
+	tests := []struct {
+		name string
+		auth ClientAuth
+		want tls.ClientAuthType
+	}{
+		{"default mode", ClientAuth{}, tls.NoClientCert},
+		{"none", ClientAuth{Mode: ClientAuthNone}, tls.NoClientCert},
+		{"request", ClientAuth{Mode: ClientAuthRequest, CAs: pool}, tls.VerifyClientCertIfGiven},
+		{"require and verify", ClientAuth{Mode: ClientAuthRequireAndVerify, CAs: pool}, tls.RequireAndVerifyClientCert},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig := &tls.Config{}
+
+			if err := tt.auth.apply(tlsConfig); err != nil {
+				t.Fatalf("apply(), got unexpected error %v", err)
+			}
+
+			if tlsConfig.ClientAuth != tt.want {
+				t.Errorf("apply(), got %v but want %v", tlsConfig.ClientAuth, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientAuth_apply_caFile(t *testing.T) {
+	//given
+	certFile, _ := writeCertificate(t, t.TempDir(), 1)
+	auth := ClientAuth{
+		Mode:   ClientAuthRequireAndVerify,
+		CAFile: certFile,
+	}
+	tlsConfig := &tls.Config{}
+
+	//when
+	err := auth.apply(tlsConfig)
+
+	//then
+	if err != nil {
+		t.Fatalf("apply(), got unexpected error %v", err)
+	}
+
+	if tlsConfig.ClientCAs == nil {
+		t.Errorf("apply(), client CAs expected")
+	}
+}
+
+func TestClientAuth_apply_error(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	os.WriteFile(invalid, []byte("invalid"), 0600)
+	tests := []struct {
+		name string
+		auth ClientAuth
+		code exceptions.Code
+	}{
+		{"unknown mode", ClientAuth{Mode: "optional"}, exceptions.ResourceInvalidCode},
+		{"missing CAs", ClientAuth{Mode: ClientAuthRequest}, exceptions.ResourceInvalidCode},
+		{"missing CA file", ClientAuth{Mode: ClientAuthRequest, CAFile: "/missing/ca.pem"}, exceptions.ResourceNotFoundCode},
+		{"invalid CA file", ClientAuth{Mode: ClientAuthRequest, CAFile: invalid}, exceptions.ResourceInvalidCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.apply(&tls.Config{})
+
+			ex, ok := err.(*exceptions.Exception)
+			if !ok || ex.Code != tt.code {
+				t.Errorf("apply(), got %v but want %v", err, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//TraceParentHeader carries the W3C trace
+//context of a request
+const TraceParentHeader = "traceparent"
+
+//TraceStateHeader carries the vendor specific
+//W3C trace context of a request
+const TraceStateHeader = "tracestate"
+
+//spanKey references the Span stored in
+//the scope by the Tracing interceptor
+const spanKey = "fwork_span"
+
+//SpanKindServer is the kind of the spans
+//started for the requests served
+const SpanKindServer = "server"
+
+const (
+	SpanStatusUnset = "unset"
+	SpanStatusOk    = "ok"
+	SpanStatusError = "error"
+)
+
+//SpanContext identifies a span within a
+//trace, as propagated by W3C trace context
+type SpanContext struct {
+	TraceID    string `json:"trace_id"`
+	SpanID     string `json:"span_id"`
+	Sampled    bool   `json:"sampled"`
+	TraceState string `json:"trace_state,omitempty"`
+}
+
+//IsValid reports if the trace and
+//span identifiers are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+//TraceParent renders the context as
+//a W3C traceparent header value
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags
+}
+
+//Span is an OpenTelemetry compatible record of
+//the work done to serve a request. Attributes
+//follow the OpenTelemetry HTTP conventions
+type Span struct {
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	Context      SpanContext    `json:"context"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status"`
+
+	mu sync.Mutex
+}
+
+//SetAttribute records an attribute in the span,
+//replacing the one recorded with the same key
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+}
+
+//SpanExporter receives every span once ended
+type SpanExporter interface {
+	Export(span *Span)
+}
+
+//InMemoryExporter keeps the exported
+//spans, mostly for tests
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+//NewInMemoryExporter creates an empty InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+//Spans retrieves the spans exported so far
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]*Span{}, e.spans...)
+}
+
+//Reset discards the spans exported so far
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
+
+//Tracing starts a server span for every request,
+//continuing the trace propagated by the traceparent
+//and tracestate headers. Spans are stored in the
+//request scope, so a single instance is safe to
+//share across requests
+type Tracing struct {
+	Exporter SpanExporter
+}
+
+//NewTracing creates a Tracing interceptor
+//exporting spans through the given exporter
+func NewTracing(exporter SpanExporter) *Tracing {
+	return &Tracing{
+		Exporter: exporter,
+	}
+}
+
+//Before starts the span of the request
+func (t *Tracing) Before(s Scope) error {
+	span := &Span{
+		Name:   s.Method(),
+		Kind:   SpanKindServer,
+		Start:  time.Now(),
+		Status: SpanStatusUnset,
+	}
+
+	if r := requestOf(s); r != nil {
+		if parent, ok := parseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+			span.Context = parent
+			span.ParentSpanID = parent.SpanID
+			span.Context.TraceState = r.Header.Get(TraceStateHeader)
+		}
+	}
+
+	if span.Context.TraceID == "" {
+		span.Context.TraceID = randomHex(16)
+		span.Context.Sampled = true
+	}
+	span.Context.SpanID = randomHex(8)
+
+	span.SetAttribute("http.request.method", s.Method())
+	span.SetAttribute("url.path", s.Path())
+	if route := routeOf(s); route != "" {
+		span.Name = s.Method() + " " + route
+		span.SetAttribute("http.route", route)
+	}
+
+	s.OverrideData(spanKey, span)
+	return nil
+}
+
+//After ends the span of the request, records
+//its status and exception code and exports it
+func (t *Tracing) After(s Scope) error {
+	span := s.Span()
+	if span == nil {
+		return nil
+	}
+
+	span.End = time.Now()
+	span.SetAttribute("http.response.status_code", s.Status())
+	if code := exceptionCodeOf(s); code != "" {
+		span.SetAttribute("exception.code", string(code))
+	}
+
+	span.Status = SpanStatusOk
+	if s.Status() >= http.StatusInternalServerError {
+		span.Status = SpanStatusError
+	}
+
+	if t.Exporter != nil {
+		t.Exporter.Export(span)
+	}
+	return nil
+}
+
+//Span retrieves the span started by the Tracing
+//interceptor. Returns nil if it is not traced
+func (s *scope) Span() *Span {
+	span, _ := s.d[spanKey].(*Span)
+	return span
+}
+
+//parseTraceParent parses a W3C traceparent header
+//(eg. "00-<trace-id>-<parent-id>-01"). Returns
+//false if the header is invalid
+func parseTraceParent(traceparent string) (SpanContext, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || !isHex(parts[0]) || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+
+	//version 00 has exactly four parts
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if len(traceID) != 32 || !isHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return SpanContext{}, false
+	}
+
+	if len(spanID) != 16 || !isHex(spanID) || strings.Trim(spanID, "0") == "" {
+		return SpanContext{}, false
+	}
+
+	if len(flags) != 2 || !isHex(flags) {
+		return SpanContext{}, false
+	}
+	sampled, _ := strconv.ParseUint(flags, 16, 8)
+
+	return SpanContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Sampled: sampled&1 == 1,
+	}, true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+//routeOf retrieves the url template matched by
+//the request. Returns an empty string if none
+//matched or the scope was not created by the engine
+func routeOf(s Scope) string {
+	if sc, ok := s.(*scope); ok {
+		return sc.route
+	}
+
+	return ""
+}
+
+//exceptionCodeOf retrieves the code of the exception
+//the scope was replied with. Returns an empty string
+//if it was not replied with an exception
+func exceptionCodeOf(s Scope) exceptions.Code {
+	if sc, ok := s.(*scope); ok {
+		return sc.code
+	}
+
+	return ""
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
+	"github.com/ravelo-systematic-solutions/fwork/response"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_parseTraceParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		expected    SpanContext
+		ok          bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}, true},
+		{"future version", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03-extra", SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, true},
+		{"empty", "", SpanContext{}, false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"extra parts", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", SpanContext{}, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", SpanContext{}, false},
+		{"upper case", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"short span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01", SpanContext{}, false},
+		{"invalid flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-x1", SpanContext{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			actual, ok := parseTraceParent(tt.traceparent)
+
+			//then
+			if ok != tt.ok {
+				t.Errorf("parseTraceParent(), got %v but want %v", ok, tt.ok)
+			}
+
+			if actual != tt.expected {
+				t.Errorf("parseTraceParent(), got %v but want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSpanContext_TraceParent(t *testing.T) {
+	//given
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, _ := parseTraceParent(expected)
+
+	//when
+	actual := sc.TraceParent()
+
+	//then
+	if actual != expected {
+		t.Errorf("TraceParent(), got %v but want %v", actual, expected)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		traceparent string
+		spanName    string
+		status      string
+		attributes  map[string]any
+	}{
+		{
+			name:        "continued trace",
+			url:         "/users/1",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			spanName:    "GET /users/{id}",
+			status:      SpanStatusOk,
+			attributes: map[string]any{
+				"http.request.method":       "GET",
+				"http.route":                "/users/{id}",
+				"url.path":                  "/users/1",
+				"http.response.status_code": http.StatusConflict,
+				"exception.code":            "fwork_rd",
+				"user.id":                   "1",
+			},
+		},
+		{
+			name:     "new trace",
+			url:      "/users/2",
+			spanName: "GET /users/{id}",
+			status:   SpanStatusError,
+			attributes: map[string]any{
+				"http.request.method":       "GET",
+				"http.route":                "/users/{id}",
+				"url.path":                  "/users/2",
+				"http.response.status_code": http.StatusInternalServerError,
+				"exception.code":            "fwork_ie",
+				"user.id":                   "2",
+			},
+		},
+		{
+			name:     "not found",
+			url:      "/unknown",
+			spanName: "GET",
+			status:   SpanStatusOk,
+			attributes: map[string]any{
+				"http.request.method":       "GET",
+				"url.path":                  "/unknown",
+				"http.response.status_code": http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			exporter := NewInMemoryExporter()
+			e := engine{
+				routes: newRouter(),
+			}
+			e.AddInterceptor(NewTracing(exporter))
+			e.routes.Add(GenerateEndpointKey(http.MethodGet, "/users/{id}"), func(s Scope) {
+				s.Span().SetAttribute("user.id", s.PathValue("id"))
+				if s.PathValue("id") == "2" {
+					panic("user 2")
+				}
+
+				ex := exceptions.NewBuilder()
+				ex.SetCode(exceptions.ResourceDuplicatedCode)
+				ex.SetMessage(exceptions.ResourceDuplicatedMessage)
+				s.Fail(ex.Build())
+			})
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.traceparent != "" {
+				r.Header.Set(TraceParentHeader, tt.traceparent)
+				r.Header.Set(TraceStateHeader, "vendor=value")
+			}
+
+			//when
+			e.ServeHTTP(w, r)
+
+			//then
+			spans := exporter.Spans()
+			if len(spans) != 1 {
+				t.Fatalf("Export(), got %v but want %v", len(spans), 1)
+			}
+			span := spans[0]
+
+			if span.Name != tt.spanName {
+				t.Errorf("Span(), got %v but want %v", span.Name, tt.spanName)
+			}
+
+			if span.Kind != SpanKindServer {
+				t.Errorf("Span(), got %v but want %v", span.Kind, SpanKindServer)
+			}
+
+			if span.Status != tt.status {
+				t.Errorf("Span(), got %v but want %v", span.Status, tt.status)
+			}
+
+			if !reflect.DeepEqual(span.Attributes, tt.attributes) {
+				t.Errorf("Span(), got %v but want %v", span.Attributes, tt.attributes)
+			}
+
+			if !span.Context.IsValid() || span.End.Before(span.Start) {
+				t.Errorf("Span(), got %v but want a valid ended span", span.Context)
+			}
+
+			parent, traced := parseTraceParent(tt.traceparent)
+			if traced && (span.Context.TraceID != parent.TraceID || span.ParentSpanID != parent.SpanID) {
+				t.Errorf("Span(), got %v but want the trace %v", span.Context, parent)
+			}
+
+			if traced && span.Context.TraceState != "vendor=value" {
+				t.Errorf("Span(), got %v but want %v", span.Context.TraceState, "vendor=value")
+			}
+
+			if !traced && span.ParentSpanID != "" {
+				t.Errorf("Span(), got %v but want a root span", span.ParentSpanID)
+			}
+		})
+	}
+}
+
+func TestScope_Span_notTraced(t *testing.T) {
+	//given
+	r, _ := http.NewRequest(http.MethodGet, "/some-url", nil)
+	s := NewScope(httptest.NewRecorder(), r)
+	s.Reply(http.StatusOK, response.Void{})
+
+	//when
+	actual := s.Span()
+
+	//then
+	if actual != nil {
+		t.Errorf("Span(), got %v but want %v", actual, nil)
+	}
+}
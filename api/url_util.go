@@ -2,7 +2,6 @@ package api
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 )
 
@@ -11,19 +10,3 @@ import (
 func GenerateEndpointKey(method, url string) string {
 	return strings.ToLower(fmt.Sprintf("%s-%s", method, url))
 }
-
-func extractValue(value reflect.Value) any {
-	switch value.Type().String() {
-	case "string":
-		return value.String()
-	case "int":
-		return value.Int()
-	case "float32":
-		return value.Float()
-	case "float64":
-		return value.Float()
-	case "bool":
-		return value.Bool()
-	}
-	return nil
-}
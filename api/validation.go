@@ -2,10 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/ravelo-systematic-solutions/fwork/exceptions"
-	"log"
 	"reflect"
-	"strconv"
+	"sort"
 	"strings"
 )
 
@@ -19,8 +19,23 @@ type ValidationRuleError struct {
 	Value      string
 }
 
+//ValidationRule is a rule registered through
+//RegisterRule which only needs the value
+//to validate. Returns true if the value
+//failed validation
 type ValidationRule func(cv any) bool
 
+//validator runs the rules of the "validate"
+//tag on behalf of a request scope
+type validator struct {
+	scope Scope
+	rules map[string]FieldRule
+
+	//unregistered collects the rules
+	//declared by fields but not registered
+	unregistered *[]string
+}
+
 //required is a rule that ensures that the
 //value is set. Returns true if the value
 //failed validation, false otherwise.
@@ -39,56 +54,39 @@ func required(v any) bool {
 //from a request using the "query" tag for the name of the
 //fields and the "validate" tag for validation rules.
 func (s *scope) ValidateQuery(payload interface{}) error {
-	dataType := reflect.TypeOf(payload).Elem()
-	dataValue := reflect.ValueOf(payload).Elem()
+	dataValue, err := structOf(payload)
+	if err != nil {
+		return err
+	}
+	dataType := dataValue.Type()
 	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+	v := s.validator()
+	query := s.r.URL.Query()
 
 	for i := 0; i < dataType.NumField(); i++ {
 		field := dataType.Field(i)
 
 		name := field.Name
 		fieldValue := dataValue.Field(i)
-		value := extractValue(fieldValue)
 		tagKey := field.Tag.Get(queryTag)
-		val := s.r.URL.Query().Get(tagKey)
-		tagValues := strings.Split(field.Tag.Get(validationTag), ",")
-
-		if fieldValue.IsValid() {
-			switch fieldValue.Type().String() {
-			case "string":
-				fieldValue.SetString(val)
-			case "int":
-				valInt, _ := strconv.ParseInt(val, 10, 64)
-				fieldValue.SetInt(valInt)
-			case "float32":
-				valFloat, _ := strconv.ParseFloat(val, 32)
-				fieldValue.SetFloat(valFloat)
-			case "float64":
-				valFloat, _ := strconv.ParseFloat(val, 64)
-				fieldValue.SetFloat(valFloat)
-			case "bool":
-				valBool, _ := strconv.ParseBool(val)
-				fieldValue.SetBool(valBool)
-			}
-		}
+		vals := withDefault(field, query[tagKey])
 
-		if len(tagValues) == 0 {
+		if err := setValues(fieldValue, vals); err != nil {
+			ex.Include(bindingData(name, err))
 			continue
 		}
 
-		for i := 0; i < len(tagValues); i++ {
-			tagValue := tagValues[i]
-			switch {
-			case tagValue == "required" && required(val):
-				ex.Include(exceptions.Data{
-					Name:  name,
-					Tag:   tagValue,
-					Value: value,
-				})
-			}
+		for _, data := range v.value(name, indirect(fieldValue), dataValue, field.Tag.Get(validationTag), sent(vals)) {
+			ex.Include(data)
 		}
 	}
 
+	if err := v.unregisteredRules(); err != nil {
+		return err
+	}
+
 	if ex.IsEmpty() {
 		return nil
 	}
@@ -100,58 +98,319 @@ func (s *scope) ValidateQuery(payload interface{}) error {
 //using the "json" tag for the name of the fields and the
 //"validate" tag for validation rules.
 func (s *scope) ValidateJsonBody(payload interface{}) error {
+	if _, err := elem(payload); err != nil {
+		return err
+	}
+
+	if err := s.defaults(payload); err != nil {
+		return err
+	}
 
 	err := json.NewDecoder(s.r.Body).Decode(payload)
 	if err != nil {
-		log.Panicf("fail to decode money")
+		ex := exceptions.NewBuilder()
+		ex.SetCode(exceptions.ResourceInvalidCode)
+		ex.SetMessage(exceptions.ResourceInvalidMessage)
+		ex.SetCause(err)
+		ex.Include(exceptions.Data{Value: err.Error()})
+
+		return ex.Build()
 	}
 
-	dataType := reflect.TypeOf(payload).Elem()
-	dataValue := reflect.ValueOf(payload).Elem()
+	return s.validator().body(payload)
+}
+
+//defaults sets the values declared by the "default"
+//tag of a body before it is decoded. An exception is
+//thrown if any of them cannot be parsed
+func (s *scope) defaults(payload interface{}) error {
 	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
 
-	for i := 0; i < dataType.NumField(); i++ {
+	for _, data := range setDefaults("", reflect.ValueOf(payload)) {
+		ex.Include(data)
+	}
+
+	if ex.IsEmpty() {
+		return nil
+	}
 
+	return ex.Build()
+}
+
+//validator retrieves the validator using the rules
+//registered in the engine or the built-in ones
+func (s *scope) validator() validator {
+	rules := s.rules
+	if rules == nil {
+		rules = defaultRules()
+	}
+
+	return validator{
+		scope:        s,
+		rules:        rules,
+		unregistered: &[]string{},
+	}
+}
+
+//body validates a decoded body using the "validate" tag
+//for validation rules. Nested structs are validated
+//recursively and failures are named after their
+//JSON path (eg. addresses[2].postal_code)
+func (v validator) body(payload interface{}) error {
+	dataValue := indirect(reflect.ValueOf(payload))
+	if dataValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+
+	for _, data := range v.fields("", dataValue) {
+		ex.Include(data)
+	}
+
+	if err := v.unregisteredRules(); err != nil {
+		return err
+	}
+
+	if ex.IsEmpty() {
+		return nil
+	}
+
+	return ex.Build()
+}
+
+//fields validates every exported field of a struct,
+//descending into nested structs and, when the "dive"
+//rule is declared, into slice and map elements
+func (v validator) fields(path string, parent reflect.Value) []exceptions.Data {
+	failed := make([]exceptions.Data, 0)
+	dataType := parent.Type()
+
+	for i := 0; i < dataType.NumField(); i++ {
 		field := dataType.Field(i)
-		name := dataType.Field(i).Name
-		fieldValue := dataValue.Field(i)
-		value := extractValue(fieldValue)
-		tags := field.Tag.Get(validationTag)
-		tagValues := strings.Split(tags, ",")
+		if !field.IsExported() {
+			continue
+		}
 
-		if len(tagValues) == 0 {
+		name, ok := jsonName(field)
+		if !ok {
 			continue
 		}
 
-		for i := 0; i < len(tagValues); i++ {
-			tagValue := tagValues[i]
-			switch {
-			case tagValue == "required":
-				if required(value) {
-					ex.Include(exceptions.Data{
-						Name:  name,
-						Tag:   tagValue,
-						Value: value,
-					})
-				}
+		fieldValue := parent.Field(i)
+		if field.Anonymous && name == "" {
+			if embedded := indirect(fieldValue); embedded.Kind() == reflect.Struct {
+				failed = append(failed, v.fields(path, embedded)...)
 			}
+			continue
 		}
+		if name == "" {
+			name = field.Name
+		}
+
+		failed = append(failed, v.field(joinPath(path, name), fieldValue, parent, field.Tag.Get(validationTag))...)
 	}
 
-	if ex.IsEmpty() {
+	return failed
+}
+
+//field validates a value with the rules declared before
+//"dive", then each of its elements with the rules
+//declared after it. Structs are validated recursively
+func (v validator) field(path string, fieldValue, parent reflect.Value, tags string) []exceptions.Data {
+	tags, elementTags, dive := cutDive(tags)
+	present := isPresent(fieldValue)
+	value := indirect(fieldValue)
+
+	failed := v.value(path, value, parent, tags, present)
+	if !present {
+		return failed
+	}
+
+	switch {
+	case dive && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array):
+		for i := 0; i < value.Len(); i++ {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			failed = append(failed, v.field(elementPath, value.Index(i), parent, elementTags)...)
+		}
+	case dive && value.Kind() == reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			elementPath := fmt.Sprintf("%s[%v]", path, key.Interface())
+			failed = append(failed, v.field(elementPath, value.MapIndex(key), parent, elementTags)...)
+		}
+	case value.Kind() == reflect.Struct:
+		failed = append(failed, v.fields(path, value)...)
+	}
+
+	return failed
+}
+
+//cutDive splits the rules declared before and
+//after the first "dive" rule
+func cutDive(tags string) (string, string, bool) {
+	rules := strings.Split(tags, ",")
+	for i, r := range rules {
+		if r == "dive" {
+			return strings.Join(rules[:i], ","), strings.Join(rules[i+1:], ","), true
+		}
+	}
+
+	return tags, "", false
+}
+
+//jsonName retrieves the name given by the "json" tag.
+//Returns false if the field is ignored by the tag
+func jsonName(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+
+	return name, true
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+//isPresent reports if a body value was sent. Nil
+//pointers, slices and maps as well as empty strings
+//are considered absent
+func isPresent(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil() && isPresent(v.Elem())
+	case reflect.Slice, reflect.Map:
+		return !v.IsNil()
+	case reflect.String:
+		return v.String() != ""
+	}
+	return true
+}
+
+//indirect dereferences pointers and interfaces
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v
+}
+
+//value runs the rules declared by the "validate" tag
+//(eg. "required,min=3") against a field of parent,
+//retrieving an entry per failed rule. Values which
+//were not sent are only checked by the required rule.
+//Rules which are not registered are collected so a
+//typo does not turn the validation off silently
+func (v validator) value(name string, field, parent reflect.Value, tags string, present bool) []exceptions.Data {
+	failed := make([]exceptions.Data, 0)
+
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		//the following rules apply to the elements
+		if tag == "dive" {
+			break
+		}
+
+		ruleName, param, _ := strings.Cut(tag, "=")
+		r, ok := v.rules[ruleName]
+		if !ok && ruleName != "required" {
+			*v.unregistered = append(*v.unregistered, fmt.Sprintf("%q of field %s", ruleName, name))
+			continue
+		}
+
+		switch {
+		case ruleName == "required":
+			if present {
+				continue
+			}
+		case !present:
+			continue
+		default:
+			ctx := FieldContext{
+				Scope:  v.scope,
+				Name:   name,
+				Value:  field,
+				Param:  param,
+				Parent: parent,
+			}
+			if !r(ctx) {
+				continue
+			}
+		}
+
+		failed = append(failed, exceptions.Data{
+			Name:  name,
+			Tag:   tag,
+			Value: interfaceOf(field),
+		})
+	}
+
+	return failed
+}
+
+//unregisteredRules builds the internal error thrown when
+//fields declare rules which are not registered, nil
+//otherwise. The rules are only part of its cause, so
+//they are logged but not exposed to the client
+func (v validator) unregisteredRules() error {
+	if v.unregistered == nil || len(*v.unregistered) == 0 {
 		return nil
 	}
 
+	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.InternalErrorCode)
+	ex.SetMessage(exceptions.InternalErrorMessage)
+	ex.SetCause(fmt.Errorf("validation rules not registered: %s", strings.Join(*v.unregistered, ", ")))
+
 	return ex.Build()
 }
 
+//interfaceOf retrieves the value held by v or
+//nil if it is a nil pointer or cannot be accessed
+func interfaceOf(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
 //ValidateHeaders extract & validates a request header
 //using the "header" tag for the name of the fields and the
 //"validate" tag for validation rules.
 func (s *scope) ValidateHeaders(payload interface{}) error {
-	dataType := reflect.TypeOf(payload).Elem()
-	dataValue := reflect.ValueOf(payload).Elem()
+	dataValue, err := structOf(payload)
+	if err != nil {
+		return err
+	}
+	dataType := dataValue.Type()
 	ex := exceptions.NewBuilder()
+	ex.SetCode(exceptions.ResourceInvalidCode)
+	ex.SetMessage(exceptions.ResourceInvalidMessage)
+	v := s.validator()
 
 	for i := 0; i < dataType.NumField(); i++ {
 		field := dataType.Field(i)
@@ -159,45 +418,22 @@ func (s *scope) ValidateHeaders(payload interface{}) error {
 		name := field.Name
 		fieldValue := dataValue.Field(i)
 		tagKey := field.Tag.Get(headerTag)
-		value := s.r.Header.Get(tagKey)
-		tagValues := strings.Split(field.Tag.Get(validationTag), ",")
-
-		if fieldValue.IsValid() {
-			switch fieldValue.Type().String() {
-			case "string":
-				fieldValue.SetString(value)
-			case "int":
-				valInt, _ := strconv.ParseInt(value, 10, 64)
-				fieldValue.SetInt(valInt)
-			case "float32":
-				valFloat, _ := strconv.ParseFloat(value, 32)
-				fieldValue.SetFloat(valFloat)
-			case "float64":
-				valFloat, _ := strconv.ParseFloat(value, 64)
-				fieldValue.SetFloat(valFloat)
-			case "bool":
-				valBool, _ := strconv.ParseBool(value)
-				fieldValue.SetBool(valBool)
-			}
-		}
+		vals := withDefault(field, s.r.Header.Values(tagKey))
 
-		if len(tagValues) == 0 {
+		if err := setValues(fieldValue, vals); err != nil {
+			ex.Include(bindingData(name, err))
 			continue
 		}
 
-		for i := 0; i < len(tagValues); i++ {
-			tagValue := tagValues[i]
-			switch {
-			case tagValue == "required" && required(value):
-				ex.Include(exceptions.Data{
-					Name:  name,
-					Tag:   tagValue,
-					Value: value,
-				})
-			}
+		for _, data := range v.value(name, indirect(fieldValue), dataValue, field.Tag.Get(validationTag), sent(vals)) {
+			ex.Include(data)
 		}
 	}
 
+	if err := v.unregisteredRules(); err != nil {
+		return err
+	}
+
 	if ex.IsEmpty() {
 		return nil
 	}
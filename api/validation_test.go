@@ -2,8 +2,10 @@ package api
 
 import (
 	"bytes"
+	"github.com/ravelo-systematic-solutions/fwork/exceptions"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -141,3 +143,151 @@ func Test_required(t *testing.T) {
 		})
 	}
 }
+
+func TestScope_JsonBody_malformed(t *testing.T) {
+	//given
+	req := httptest.NewRequest(http.MethodPost, "/some-url", bytes.NewReader([]byte("{\"s\":")))
+	scope := scope{
+		r: req,
+	}
+
+	var actual Sample
+
+	//when
+	err := scope.ValidateJsonBody(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok || ex.Code != exceptions.ResourceInvalidCode {
+		t.Errorf("ValidateJsonBody() got %v but want %v", err, exceptions.ResourceInvalidCode)
+	}
+}
+
+func TestScope_Query_rules(t *testing.T) {
+	//given
+	type Page struct {
+		Limit int    `query:"limit" validate:"min=1,max=100"`
+		Sort  string `query:"sort" validate:"oneof=asc desc"`
+		Email string `query:"email" validate:"email"`
+		Since string `query:"since" validate:"datetime=2006-01-02"`
+	}
+	var actual Page
+	url := "/some-url?limit=500&sort=up&since=2022-12-31"
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	scope := scope{
+		r: req,
+	}
+	expected := []exceptions.Data{
+		{Name: "Limit", Tag: "max=100", Value: 500},
+		{Name: "Sort", Tag: "oneof=asc desc", Value: "up"},
+	}
+
+	//when
+	err := scope.ValidateQuery(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateQuery() exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateQuery() got %v but want %v", ex.Data, expected)
+	}
+}
+
+func TestScope_JsonBody_rules(t *testing.T) {
+	//given
+	type User struct {
+		Id    string `json:"id" validate:"required,uuid"`
+		Name  string `json:"name" validate:"required,min=2"`
+		Age   int    `json:"age" validate:"gt=0,lt=150"`
+		Site  string `json:"site" validate:"url"`
+		Email string `json:"email" validate:"required,email"`
+	}
+	body := []byte(`{"id":"1","name":"A","age":0,"site":"https://company.com"}`)
+	req := httptest.NewRequest(http.MethodPost, "/some-url", bytes.NewReader(body))
+	scope := scope{
+		r: req,
+	}
+	var actual User
+	expected := []exceptions.Data{
+		{Name: "id", Tag: "uuid", Value: "1"},
+		{Name: "name", Tag: "min=2", Value: "A"},
+		{Name: "age", Tag: "gt=0", Value: 0},
+		{Name: "email", Tag: "required", Value: ""},
+	}
+
+	//when
+	err := scope.ValidateJsonBody(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateJsonBody() exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateJsonBody() got %v but want %v", ex.Data, expected)
+	}
+}
+
+func TestScope_JsonBody_nested(t *testing.T) {
+	//given
+	type Address struct {
+		Street     string `json:"street" validate:"required"`
+		PostalCode string `json:"postal_code" validate:"required,len=6"`
+	}
+	type Audit struct {
+		Author string `json:"author" validate:"required"`
+	}
+	type Customer struct {
+		Audit
+		Name      string            `json:"name" validate:"required"`
+		Nickname  *string           `json:"nickname" validate:"min=3"`
+		Billing   *Address          `json:"billing" validate:"required"`
+		Shipping  *Address          `json:"shipping"`
+		Addresses []Address         `json:"addresses" validate:"min=1,dive"`
+		Tags      []string          `json:"tags" validate:"dive,oneof=vip new"`
+		Labels    map[string]string `json:"labels" validate:"dive,max=3"`
+		Ignored   Address           `json:"-"`
+	}
+	body := []byte(`{
+		"name": "Art",
+		"nickname": "A",
+		"shipping": {"street": "Main", "postal_code": "A0A0A0"},
+		"addresses": [
+			{"street": "Main", "postal_code": "A0A0A0"},
+			{"street": "", "postal_code": "A0A"}
+		],
+		"tags": ["vip", "old"],
+		"labels": {"b": "long", "a": "ok"}
+	}`)
+	req := httptest.NewRequest(http.MethodPost, "/some-url", bytes.NewReader(body))
+	scope := scope{
+		r: req,
+	}
+	var actual Customer
+	expected := []exceptions.Data{
+		{Name: "author", Tag: "required", Value: ""},
+		{Name: "nickname", Tag: "min=3", Value: "A"},
+		{Name: "billing", Tag: "required", Value: nil},
+		{Name: "addresses[1].street", Tag: "required", Value: ""},
+		{Name: "addresses[1].postal_code", Tag: "len=6", Value: "A0A"},
+		{Name: "tags[1]", Tag: "oneof=vip new", Value: "old"},
+		{Name: "labels[b]", Tag: "max=3", Value: "long"},
+	}
+
+	//when
+	err := scope.ValidateJsonBody(&actual)
+
+	//then
+	ex, ok := err.(*exceptions.Exception)
+	if !ok {
+		t.Fatalf("ValidateJsonBody() exception expected but got %v", err)
+	}
+
+	if !reflect.DeepEqual(ex.Data, expected) {
+		t.Errorf("ValidateJsonBody() got %v but want %v", ex.Data, expected)
+	}
+}
@@ -1,5 +1,11 @@
 package exceptions
 
+import "runtime"
+
+//maxStackDepth bounds the frames
+//captured by CaptureStack
+const maxStackDepth = 32
+
 //builder eases the generation of
 //exceptions by using the Builder
 //design pattern.
@@ -38,6 +44,22 @@ func (b *builder) SetCode(code Code) {
 	b.Code = code
 }
 
+//SetCause sets the error which caused the
+//exception. It is retrieved through
+//Unwrap but never serialized
+func (b *builder) SetCause(err error) {
+	b.cause = err
+}
+
+//CaptureStack records the stack trace of
+//its caller. It is retrieved through
+//Stack but never serialized
+func (b *builder) CaptureStack() {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	b.stack = pcs[:n]
+}
+
 //IsEmpty retrieves if the exception
 //contains metadata
 func (b *builder) IsEmpty() bool {
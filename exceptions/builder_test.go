@@ -1,9 +1,13 @@
 package exceptions_test
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/ravelo-systematic-solutions/fwork/exceptions"
 	"github.com/ravelo-systematic-solutions/fwork/testutils"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -78,3 +82,45 @@ func TestBuilder_IsEmpty_false(t *testing.T) {
 		t.Errorf("IsEmpty(), should be true")
 	}
 }
+
+func TestBuilder_SetCause(t *testing.T) {
+	//given
+	cause := io.ErrUnexpectedEOF
+	b := exceptions.NewBuilder()
+	b.SetCode(exceptions.ResourceInvalidCode)
+	b.SetMessage(exceptions.ResourceInvalidMessage)
+
+	//when
+	b.SetCause(cause)
+
+	//then
+	actual := b.Build()
+	if !errors.Is(actual, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(), got %v but want %v", actual.Unwrap(), cause)
+	}
+
+	body, _ := json.Marshal(actual)
+	expected := `{"code":"fwork_ri","message":"resource invalid"}`
+	if string(body) != expected {
+		t.Errorf("json.Marshal(), got %s but want %s", body, expected)
+	}
+}
+
+func TestBuilder_CaptureStack(t *testing.T) {
+	//given
+	b := exceptions.NewBuilder()
+
+	//when
+	b.CaptureStack()
+
+	//then
+	actual := b.Build().Stack()
+	if !strings.Contains(actual, "TestBuilder_CaptureStack") {
+		t.Errorf("Stack(), got %s but want the caller", actual)
+	}
+
+	body, _ := json.Marshal(b.Build())
+	if strings.Contains(string(body), "TestBuilder_CaptureStack") {
+		t.Errorf("json.Marshal(), got %s but want no stack", body)
+	}
+}
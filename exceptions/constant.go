@@ -10,6 +10,13 @@ const (
 	ResourceNotFoundCode          = "fwork_rnf"
 	ResourceInvalidCode           = "fwork_ri"
 	ResourceClosedCode            = "fwork_rc"
+	ResourceNotStartedCode        = "fwork_rns"
+	ResourceNotClosedCode         = "fwork_rnc"
+	ResourceUnsupportedCode       = "fwork_ru"
+	InternalErrorCode             = "fwork_ie"
+	ResourceTimedOutCode          = "fwork_rto"
+	ResourceCanceledCode          = "fwork_rca"
+	ResourceTooLargeCode          = "fwork_rtl"
 )
 
 type Message string
@@ -22,4 +29,11 @@ const (
 	ResourceNotFoundMessage             = "resource not found"
 	ResourceInvalidMessage              = "resource invalid"
 	ResourceClosedMessage               = "resource closed"
+	ResourceNotStartedMessage           = "resource not started"
+	ResourceNotClosedMessage            = "resource not closed"
+	ResourceUnsupportedMessage          = "resource unsupported"
+	InternalErrorMessage                = "internal error"
+	ResourceTimedOutMessage             = "resource timed out"
+	ResourceCanceledMessage             = "resource canceled"
+	ResourceTooLargeMessage             = "resource too large"
 )
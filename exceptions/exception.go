@@ -1,6 +1,10 @@
 package exceptions
 
-import "fmt"
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
 
 //ExceptionBlueprint declares what it is
 //needed to create custom exceptions
@@ -21,15 +25,70 @@ type Exception struct {
 	Code    Code    `json:"code" bson:"code" xml:"code" yaml:"code" asn1:"utf8"`
 	Message Message `json:"message" bson:"message" xml:"message" yaml:"message" asn1:"utf8"`
 	Data    []Data  `json:"data,omitempty" bson:"data,omitempty" xml:"data,omitempty" yaml:"data,omitempty" asn1:"utf8"`
+
+	//RequestID correlates the exception
+	//with the request which threw it
+	RequestID string `json:"request_id,omitempty" bson:"request_id,omitempty" xml:"request_id,omitempty" yaml:"request_id,omitempty" asn1:"utf8"`
+
+	//cause and stack are kept unexported
+	//so they are never serialized
+	cause error
+	stack []uintptr
 }
 
 //Error ensures that the struct
 //implements the Error interface
 func (e *Exception) Error() string {
-	return fmt.Sprintf(
+	msg := fmt.Sprintf(
 		"E{%v}:M{%v}:P{%v}",
 		e.Code,
 		e.Message,
 		e.Data,
 	)
+
+	if e.cause != nil {
+		msg += fmt.Sprintf(":C{%v}", e.cause)
+	}
+
+	return msg
+}
+
+//Unwrap retrieves the error which caused
+//the exception, if any, so it can be
+//inspected by errors.Is and errors.As
+func (e *Exception) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+
+	return e.cause
+}
+
+//Is reports if the target is an exception
+//with the same code, so errors.Is can
+//match exceptions by their code
+func (e *Exception) Is(target error) bool {
+	t, ok := target.(*Exception)
+	return ok && t.Code == e.Code
+}
+
+//Stack retrieves the stack trace captured
+//when the exception was built. Returns an
+//empty string if it was not captured
+func (e *Exception) Stack() string {
+	if e == nil || len(e.stack) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return sb.String()
 }
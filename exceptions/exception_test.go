@@ -1,6 +1,8 @@
 package exceptions
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -25,3 +27,107 @@ func TestException_Error(t *testing.T) {
 		t.Errorf("got %s but want %s", actual, expected)
 	}
 }
+
+func TestException_Error_cause(t *testing.T) {
+	//given
+	expected := "E{0123}:M{Hello World}:P{[]}:C{db unreachable}"
+	e := Exception{
+		Code:    "0123",
+		Message: "Hello World",
+		Data:    []Data{},
+		cause:   errors.New("db unreachable"),
+	}
+
+	//when
+	actual := e.Error()
+
+	//then
+	if expected != actual {
+		t.Errorf("got %s but want %s", actual, expected)
+	}
+}
+
+func TestException_Is(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   error
+		expected bool
+	}{
+		{"same code", &Exception{Code: ResourceNotFoundCode}, true},
+		{"other code", &Exception{Code: ResourceInvalidCode}, false},
+		{"cause", errNoRows, true},
+		{"other error", errors.New("other"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			e := &Exception{
+				Code:    ResourceNotFoundCode,
+				Message: ResourceNotFoundMessage,
+				cause:   fmt.Errorf("user 1: %w", errNoRows),
+			}
+
+			//when
+			actual := errors.Is(e, tt.target)
+
+			//then
+			if actual != tt.expected {
+				t.Errorf("errors.Is(), got %v but want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestException_As(t *testing.T) {
+	//given
+	e := &Exception{
+		Code:  ResourceInvalidCode,
+		cause: fmt.Errorf("wrapped: %w", &pathError{}),
+	}
+
+	//when
+	var actual *pathError
+	ok := errors.As(e, &actual)
+
+	//then
+	if !ok {
+		t.Errorf("errors.As(), got %v but want %v", ok, true)
+	}
+}
+
+func TestException_As_wrapped(t *testing.T) {
+	//given
+	e := &Exception{Code: ResourceNotFoundCode}
+	err := fmt.Errorf("load user: %w", e)
+
+	//when
+	var actual *Exception
+	ok := errors.As(err, &actual)
+
+	//then
+	if !ok || actual.Code != ResourceNotFoundCode {
+		t.Errorf("errors.As(), got %v but want %v", actual, ResourceNotFoundCode)
+	}
+}
+
+func TestException_nil(t *testing.T) {
+	//given
+	var e *Exception
+
+	//when
+	cause := e.Unwrap()
+	stack := e.Stack()
+
+	//then
+	if cause != nil || stack != "" {
+		t.Errorf("Unwrap(), Stack(), got %v and %q but want nil and empty", cause, stack)
+	}
+}
+
+var errNoRows = errors.New("no rows")
+
+type pathError struct{}
+
+func (p *pathError) Error() string {
+	return "path"
+}
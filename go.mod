@@ -1,3 +1,5 @@
 module github.com/ravelo-systematic-solutions/fwork
 
-go 1.18
+go 1.19
+
+require gopkg.in/yaml.v3 v3.0.1